@@ -0,0 +1,58 @@
+package todo
+
+import "time"
+
+// ArchivePath is where the archive for the data file at path is kept.
+func ArchivePath(path string) string {
+	return path + ".archive"
+}
+
+// Archive moves the completed top-level todos that were completed before
+// cutoff, together with their subtasks, to the end of archive. A todo the
+// archive holds already, because archiving it was undone, replaces the
+// archived copy. It returns how many todos were moved.
+func (t *Todos) Archive(archive *Todos, cutoff time.Time) int {
+	kept := Todos{}
+	moved := 0
+	for _, i := range *t {
+		if i.Done && i.CompletedAt.Before(cutoff) {
+			archive.drop(i.ID)
+			*archive = append(*archive, i)
+			moved++
+			continue
+		}
+		kept = append(kept, i)
+	}
+
+	*t = kept
+
+	return moved
+}
+
+// drop removes the top-level todo with the given ID, if there is one.
+func (t *Todos) drop(id int) {
+	for idx := range *t {
+		if (*t)[idx].ID == id {
+			*t = append((*t)[:idx], (*t)[idx+1:]...)
+			return
+		}
+	}
+}
+
+// SyncArchive updates archive after an archive operation was undone or
+// redone, turning the list from before into after: todos back in the list
+// are taken out of the archive, and top-level todos that left the list are
+// put back into it.
+func SyncArchive(archive *Todos, before, after Todos) {
+	kept := map[int]bool{}
+	for _, i := range after {
+		kept[i.ID] = true
+		archive.drop(i.ID)
+	}
+	for _, i := range before {
+		if !kept[i.ID] {
+			archive.drop(i.ID)
+			*archive = append(*archive, i)
+		}
+	}
+}
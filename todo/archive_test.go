@@ -0,0 +1,124 @@
+package todo_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/example/todo"
+)
+
+func TestArchive(t *testing.T) {
+	todos := todo.Todos{}
+	todos.Add("open")
+	done := todos.Add("done")
+	todos.AddSub(done, "done subtask")
+	sub, _ := todos.AddSub(todos.Add("open parent"), "done subtask of an open todo")
+	if err := todos.ForceComplete(done); err != nil {
+		t.Fatal(err)
+	}
+	if err := todos.Complete(sub); err != nil {
+		t.Fatal(err)
+	}
+
+	archive := todo.Todos{}
+	if n := todos.Archive(&archive, time.Now().Add(-time.Hour)); n != 0 {
+		t.Errorf("archived %d todos completed after the cutoff", n)
+	}
+
+	if n := todos.Archive(&archive, time.Now().Add(time.Hour)); n != 1 {
+		t.Errorf("archived %d todos, want 1", n)
+	}
+	if got, want := tasks(todos), []string{"open", "open parent"}; !equal(got, want) {
+		t.Errorf("kept %v, want %v", got, want)
+	}
+	if got, want := tasks(archive), []string{"done"}; !equal(got, want) {
+		t.Errorf("archived %v, want %v", got, want)
+	}
+	if got := tasks(archive[0].Children); !equal(got, []string{"done subtask"}) {
+		t.Errorf("archived subtasks %v", got)
+	}
+
+	// Archiving a todo again after an undo replaces the archived copy.
+	todos = append(todos, archive[0])
+	if n := todos.Archive(&archive, time.Now().Add(time.Hour)); n != 1 {
+		t.Errorf("archived %d todos, want 1", n)
+	}
+	if got := tasks(archive); !equal(got, []string{"done"}) {
+		t.Errorf("archived %v after archiving again", got)
+	}
+}
+
+func TestArchiveUndo(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "todos.json")
+	archiveFile := todo.ArchivePath(path)
+	journal := &todo.Journal{Path: todo.JournalPath(path)}
+
+	l := &todo.List{}
+	l.Add("open")
+	if err := l.Complete(l.Add("done")); err != nil {
+		t.Fatal(err)
+	}
+	archived := &todo.Todos{}
+	before := l.Clone()
+	l.Archive(archived, time.Now().Add(time.Hour))
+	if err := journal.RecordArchive("archive 1 todos", before, l.Todos); err != nil {
+		t.Fatal(err)
+	}
+
+	// step undoes or redoes the archive and writes both files, as todo does.
+	step := func(fn func(*todo.Todos) (todo.Operation, error)) {
+		t.Helper()
+		before := l.Clone()
+		op, err := fn(&l.Todos)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !op.Archive {
+			t.Fatalf("%q not marked as an archive operation", op.Description)
+		}
+		archived := &todo.Todos{}
+		if err := archived.Load(archiveFile); err != nil {
+			t.Fatal(err)
+		}
+		todo.SyncArchive(archived, before, l.Todos)
+		if err := archived.Store(archiveFile); err != nil {
+			t.Fatal(err)
+		}
+		if err := l.Store(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// check loads both files and compares their tasks.
+	check := func(list, archive []string) {
+		t.Helper()
+		loaded, archived := &todo.List{}, &todo.Todos{}
+		if err := loaded.Load(path); err != nil {
+			t.Fatal(err)
+		}
+		if err := archived.Load(archiveFile); err != nil {
+			t.Fatal(err)
+		}
+		if got := tasks(loaded.Todos); !equal(got, list) {
+			t.Errorf("list has %v, want %v", got, list)
+		}
+		if got := tasks(*archived); !equal(got, archive) {
+			t.Errorf("archive has %v, want %v", got, archive)
+		}
+	}
+
+	if err := archived.Store(archiveFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Store(path); err != nil {
+		t.Fatal(err)
+	}
+	check([]string{"open"}, []string{"done"})
+
+	step(journal.Undo)
+	check([]string{"open", "done"}, nil)
+
+	step(journal.Redo)
+	check([]string{"open"}, []string{"done"})
+}
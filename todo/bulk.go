@@ -0,0 +1,184 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// idRange is an inclusive range of IDs; a single ID has From == To.
+type idRange struct {
+	From, To int
+}
+
+// SelectIDs returns the IDs of the todos named by s, in list order, with
+// subtasks after their parents. s is either a list of IDs and ranges such
+// as 1,3,5-8 or a query expression such as done:true. A range takes the
+// todos whose IDs fall within it, while a single ID must exist.
+func (t *Todos) SelectIDs(s string) ([]int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, errors.New("no todos selected")
+	}
+
+	var match func(i item) bool
+	if strings.Trim(s, "0123456789,- ") == "" {
+		ranges, err := parseIDRanges(s)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range ranges {
+			if r.From != r.To {
+				continue
+			}
+			if _, _, err := t.find(r.From); err != nil {
+				return nil, err
+			}
+		}
+		match = func(i item) bool {
+			for _, r := range ranges {
+				if i.ID >= r.From && i.ID <= r.To {
+					return true
+				}
+			}
+			return false
+		}
+	} else {
+		q, err := ParseQuery(s)
+		if err != nil {
+			return nil, err
+		}
+		match = q.Match
+	}
+
+	var ids []int
+	t.walk(func(_ int, i *item) {
+		if match(*i) {
+			ids = append(ids, i.ID)
+		}
+	})
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no todos match %q", s)
+	}
+
+	return ids, nil
+}
+
+// parseIDRanges reads a comma-separated list of IDs and ranges.
+func parseIDRanges(s string) ([]idRange, error) {
+	var ranges []idRange
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		from, to, isRange := strings.Cut(part, "-")
+		if !isRange {
+			to = from
+		}
+
+		r := idRange{}
+		var err error
+		if r.From, err = strconv.Atoi(strings.TrimSpace(from)); err != nil || r.From <= 0 {
+			return nil, fmt.Errorf("invalid ID %q", part)
+		}
+		if r.To, err = strconv.Atoi(strings.TrimSpace(to)); err != nil || r.To < r.From {
+			return nil, fmt.Errorf("invalid range %q", part)
+		}
+		ranges = append(ranges, r)
+	}
+
+	return ranges, nil
+}
+
+// CompleteAll completes the todos with the given IDs, skipping those done
+// already. Subtasks are completed before their parents, so a todo can be
+// completed together with its selected subtasks. If any of the todos
+// cannot be completed, none is.
+func (t *Todos) CompleteAll(ids []int, force bool) error {
+	return t.completeAll(ids, force, nil)
+}
+
+// completeAll is CompleteAll taking new IDs for recurring todos from next,
+// or from the list being changed if next is nil.
+func (t *Todos) completeAll(ids []int, force bool, next func() int) error {
+	selected := map[int]bool{}
+	for _, id := range ids {
+		if _, _, err := t.find(id); err != nil {
+			return err
+		}
+		selected[id] = true
+	}
+
+	done := t.Clone()
+	if next == nil {
+		next = done.nextID
+	}
+
+	var order []int
+	done.walk(func(_ int, i *item) {
+		if selected[i.ID] && !i.Done {
+			order = append(order, i.ID)
+		}
+	})
+
+	// Going through the list backwards reaches subtasks before parents.
+	for idx := len(order) - 1; idx >= 0; idx-- {
+		if err := done.completeID(order[idx], force, next); err != nil {
+			return err
+		}
+	}
+
+	*t = done
+
+	return nil
+}
+
+// DeleteAll removes the todos with the given IDs, together with their
+// subtasks, in one pass. If any of the IDs is unknown, nothing is deleted.
+func (t *Todos) DeleteAll(ids []int) error {
+	remove := map[int]bool{}
+	for _, id := range ids {
+		if _, _, err := t.find(id); err != nil {
+			return err
+		}
+		remove[id] = true
+	}
+
+	*t = t.without(remove)
+
+	return nil
+}
+
+func (t Todos) without(remove map[int]bool) Todos {
+	kept := make(Todos, 0, len(t))
+	for _, i := range t {
+		if remove[i.ID] {
+			continue
+		}
+		if len(i.Children) > 0 {
+			i.Children = i.Children.without(remove)
+		}
+		kept = append(kept, i)
+	}
+
+	return kept
+}
+
+// Pick returns the todos with the given IDs as a flat list in list order,
+// without their subtasks.
+func (t *Todos) Pick(ids []int) Todos {
+	picked := map[int]bool{}
+	for _, id := range ids {
+		picked[id] = true
+	}
+
+	selected := Todos{}
+	t.walk(func(_ int, i *item) {
+		if picked[i.ID] {
+			flat := *i
+			flat.Children = nil
+			selected = append(selected, flat)
+		}
+	})
+
+	return selected
+}
@@ -6,30 +6,262 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/example/todo"
+	"golang.org/x/term"
 )
 
 const (
-	todoFile = ".todos.json"
+	storageEnv = "TODO_STORAGE"
+
+	// The passphrase of an encrypted list, and the new one for
+	// -encrypt and -change-passphrase. They are prompted for if unset.
+	passphraseEnv    = "TODO_PASSPHRASE"
+	newPassphraseEnv = "TODO_NEW_PASSPHRASE"
+
+	historySize = 20
+
+	// statsDays is the default window of -stats.
+	statsDays = 14
 )
 
 func main() {
 	add := flag.Bool("add", false, "add a new todo")
-	complete := flag.Int("complete", 0, "mark a todo as completed")
-	delete := flag.Int("delete", 0, "delete a todo")
-	list := flag.Bool("list", false, "list all todos")
+	complete := flag.String("complete", "", "mark the todos with the given IDs (e.g. 3 or 1,3,5-8) or matching a query (e.g. tag:release) as completed")
+	delete := flag.String("delete", "", "delete the todos with the given IDs (e.g. 3 or 1,3,5-8) or matching a query (e.g. done:true)")
+	dryRun := flag.Bool("dry-run", false, "show the todos -complete or -delete would change without changing them")
+	edit := flag.Int("edit", 0, "replace the text of the todo with the given ID")
+	reopen := flag.Int("reopen", 0, "mark the completed todo with the given ID as not done")
+	move := flag.Int("move", 0, "move the todo with the given ID to the position given by -to")
+	to := flag.Int("to", 0, "position among its siblings to move a todo to with -move")
+	list := flag.Bool("list", false, "list all todos, or those matching the query given as arguments")
+	format := flag.String("format", todo.OutputTable, "output format of -list and -archived (table, json, csv or plain) and of -stats (table or json)")
+	sortBy := flag.String("sort", "", "order -list and -archived by id, created, completed, due, priority or text, with :asc or :desc")
+	columns := flag.String("columns", "", "comma-separated columns shown by -list and -archived (default "+strings.Join(todo.DefaultColumns, ",")+")")
+	export := flag.String("export", "", "write all todos to stdout as todotxt, csv, markdown or ical")
+	importFormat := flag.String("import", "", "merge todos from the files given as arguments (or stdin) in todotxt, csv, markdown or ical format")
+	archive := flag.Bool("archive", false, "move completed todos older than -older-than to the archive")
+	olderThan := flag.String("older-than", "30d", "minimum age of completed todos moved by -archive, e.g. 30d or 12h")
+	archived := flag.Bool("archived", false, "list the archived todos")
+	start := flag.Int("start", 0, "start tracking time on the todo with the given ID, stopping the running one")
+	stop := flag.Bool("stop", false, "stop tracking time on the running todo")
+	report := flag.Bool("report", false, "total the tracked time per task, tag and day")
+	stats := flag.Bool("stats", false, "show completion statistics and a burndown chart, by default for the last two weeks")
+	from := flag.String("from", "", "only list todos completed, report time tracked or show statistics on or after this date")
+	until := flag.String("until", "", "only list todos completed, report time tracked or show statistics before this date")
+	undo := flag.Bool("undo", false, "undo the last operation")
+	redo := flag.Bool("redo", false, "redo the last undone operation")
+	history := flag.Bool("history", false, "show the recent operations")
+	parent := flag.Int("parent", 0, "add the todo as a subtask of the todo with the given ID")
+	force := flag.Bool("force", false, "complete a todo even if it has open subtasks, or -migrate over a list that holds todos")
+	priority := flag.String("priority", "", "priority of the added todo (low, medium, high)")
+	due := flag.String("due", "", "due date of the added or snoozed todo, e.g. 2026-05-01, tomorrow 9am, next friday, in 3 days or eod")
+	snooze := flag.Int("snooze", 0, "move the due date of the todo with the given ID to -due, by default tomorrow")
+	repeat := flag.String("repeat", "", "make the added todo recur: daily, weekly, weekly:mon,thu, monthly or \"every N days\"")
+	project := flag.String("project", "", "comma-separated +projects to add the todo to, or to filter the list by")
+	tag := flag.String("tag", "", "comma-separated @context tags to add to the todo, or to filter the list by")
+	backend := flag.String("storage", defaultBackend(), "storage backend (json, sqlite), also set by $"+storageEnv)
+	interactive := flag.Bool("i", false, "browse and change the todos in an interactive full screen view")
+	serve := flag.String("serve", "", "serve the todos over HTTP on the given address, e.g. :8080")
+	listName := flag.String("L", todo.DefaultList, "name of the list to work on")
+	lists := flag.Bool("lists", false, "show all lists with their pending todos")
+	merge := flag.Bool("merge", false, "three-way merge the task files base, ours and theirs given as arguments into ours, as a git merge driver")
+	pretty := flag.Bool("pretty", false, "store the JSON indented, also set by PrettyJSON in the config file")
+	migrate := flag.String("migrate", "", "copy every todo from the current storage backend to the given one")
+	encrypt := flag.Bool("encrypt", false, "encrypt the list, its journal and its archive with a new passphrase, taken from $"+newPassphraseEnv+" or prompted for")
+	decrypt := flag.Bool("decrypt", false, "store the encrypted list, its journal and its archive in the clear again")
+	changePassphrase := flag.Bool("change-passphrase", false, "encrypt the list, its journal and its archive with a new passphrase")
 
 	flag.Parse()
 
-	todos := &todo.Todos{}
+	todo.Colors = todo.UseColors(os.Stdout)
+
+	config, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	todo.PrettyJSON = config.PrettyJSON || *pretty
+
+	hooks, err := config.Hooks()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	if *merge {
+		for _, name := range flag.Args() {
+			if encrypted, err := todo.IsEncrypted(name); err == nil && encrypted {
+				todo.Passphrase, err = readPassphrase(passphraseEnv, "Passphrase: ")
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err.Error())
+					os.Exit(2)
+				}
+				break
+			}
+		}
+
+		conflicts, err := mergeFiles(flag.Args()...)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(2)
+		}
+
+		for _, c := range conflicts {
+			fmt.Fprintln(os.Stderr, "conflict: "+c.String())
+		}
+		if len(conflicts) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	dir, err := dataDir(config)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	if *lists {
+		// Lists that need a passphrase are shown as encrypted rather
+		// than prompting for each.
+		todo.Passphrase = os.Getenv(passphraseEnv)
+
+		found, err := todo.Lists(dir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		todo.PrintLists(found, *listName)
+		return
+	}
+
+	path, err := todo.ListPath(dir, *listName, *backend)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	store, err := todo.NewStorage(*backend, path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	if *listName == todo.DefaultList && *backend == todo.BackendJSON {
+		if err := moveLegacy(path); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+	}
+
+	if (*encrypt || *decrypt || *changePassphrase) && *backend != todo.BackendJSON {
+		fmt.Fprintf(os.Stderr, "encryption needs the %s storage backend\n", todo.BackendJSON)
+		os.Exit(1)
+	}
 
-	if err := todos.Load(todoFile); err != nil {
+	encrypted, err := todo.IsEncrypted(path)
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
 	}
+	if encrypted {
+		todo.Passphrase, err = readPassphrase(passphraseEnv, "Passphrase: ")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+	}
+
+	if *interactive {
+		list := todo.NewSharedList(store, path)
+		list.Hooks = hooks
+		err := todo.Interactive(list, os.Stdin, os.Stdout)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *serve != "" {
+		fmt.Fprintf(os.Stdout, "serving todos on %s\n", *serve)
+		server := todo.NewServer(store, path)
+		server.List.Hooks = hooks
+		err := http.ListenAndServe(*serve, server)
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	unlock, err := todo.Lock(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	defer unlock()
+
+	if *migrate != "" {
+		// Only the json backend encrypts, so migrating elsewhere would
+		// write the todos in the clear.
+		if todo.Passphrase != "" && *migrate != todo.BackendJSON {
+			fmt.Fprintf(os.Stderr, "the list is encrypted and the %s storage backend cannot encrypt; run -decrypt first\n", *migrate)
+			os.Exit(1)
+		}
+
+		targetPath, err := todo.ListPath(dir, *listName, *migrate)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		target, err := todo.NewStorage(*migrate, targetPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		unlockTarget, err := todo.Lock(targetPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		defer unlockTarget()
+
+		n, err := todo.Migrate(store, target, *force)
+		if errors.Is(err, todo.ErrNotEmpty) {
+			fmt.Fprintf(os.Stderr, "%s: %s; pass -force to replace them\n", targetPath, err)
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		fmt.Fprintf(os.Stdout, "migrated %d todos from %s to %s\n", n, *backend, *migrate)
+		return
+	}
+
+	todos := &todo.List{}
+
+	if err := store.Load(todos); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	journal, err := todo.LoadJournal(todo.JournalPath(path))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	before := todos.Clone()
 
 	switch {
 	case *add:
@@ -39,38 +271,487 @@ func main() {
 			os.Exit(1)
 		}
 
-		todos.Add(task)
-		err = todos.Store(todoFile)
+		p, err := todo.ParsePriority(*priority)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		var recur *todo.Recurrence
+		if *repeat != "" {
+			recur, err = todo.ParseRecurrence(*repeat)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+		}
+
+		var dueAt time.Time
+		if *due != "" {
+			dueAt, err = todo.ParseDate(*due, todo.Clock())
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+		}
+
+		id := 0
+		if *parent > 0 {
+			id, err = todos.AddSub(*parent, task)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+		} else {
+			id = todos.Add(task)
+		}
+		todos.SetPriority(id, p)
+		todos.SetDue(id, dueAt)
+		todos.SetRecurrence(id, recur)
+		todos.AddTags(id, splitList(*project), splitList(*tag))
+
+		err = save(store, journal, hooks, fmt.Sprintf("add %d: %s", id, task), before, todos)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+	case *complete != "":
+		ids, err := todos.SelectIDs(*complete)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		if *dryRun {
+			err := preview(&todos.Todos, ids, "complete", *format, *columns)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			return
+		}
+
+		err = todos.CompleteAll(ids, *force)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		err = save(store, journal, hooks, "complete "+formatIDs(ids), before, todos)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+	case *delete != "":
+		ids, err := todos.SelectIDs(*delete)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		if *dryRun {
+			err := preview(&todos.Todos, ids, "delete", *format, *columns)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			return
+		}
+
+		err = todos.DeleteAll(ids)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		err = save(store, journal, hooks, "delete "+formatIDs(ids), before, todos)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+	case *edit > 0:
+		task, err := getInput(os.Stdin, flag.Args()...)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		err = todos.Edit(*edit, task)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		err = save(store, journal, hooks, fmt.Sprintf("edit %d: %s", *edit, task), before, todos)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+	case *reopen > 0:
+		err := todos.Reopen(*reopen)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		err = save(store, journal, hooks, fmt.Sprintf("reopen %d", *reopen), before, todos)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+	case *move > 0:
+		err := todos.Move(*move, *to)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		err = save(store, journal, hooks, fmt.Sprintf("move %d to %d", *move, *to), before, todos)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+	case *snooze > 0:
+		until := *due
+		if until == "" {
+			until = "tomorrow"
+		}
+
+		dueAt, err := todo.ParseDate(until, todo.Clock())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		err = todos.SetDue(*snooze, dueAt)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		err = save(store, journal, hooks, fmt.Sprintf("snooze %d until %s", *snooze, dueAt.Format("2006-01-02 15:04")), before, todos)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+	case *start > 0:
+		err := todos.Start(*start)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		err = save(store, journal, hooks, fmt.Sprintf("start %d", *start), before, todos)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+	case *stop:
+		id, err := todos.Stop()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		err = save(store, journal, hooks, fmt.Sprintf("stop %d", id), before, todos)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+	case *report:
+		fromTime, untilTime, err := dateRange(*from, *until)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		archivedTodos := todo.Todos{}
+		if err := archivedTodos.Load(todo.ArchivePath(path)); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		todos.Report(archivedTodos, fromTime, untilTime).Print()
+	case *stats:
+		fromTime, untilTime, err := dateRange(*from, *until)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		if untilTime.IsZero() {
+			y, m, d := todo.Clock().Date()
+			untilTime = time.Date(y, m, d+1, 0, 0, 0, 0, time.Local)
+		}
+		if fromTime.IsZero() {
+			fromTime = untilTime.AddDate(0, 0, -statsDays)
+		}
+
+		archivedTodos := todo.Todos{}
+		if err := archivedTodos.Load(todo.ArchivePath(path)); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		s := todos.Stats(archivedTodos, fromTime, untilTime)
+		switch *format {
+		case todo.OutputTable:
+			s.Print()
+		case todo.OutputJSON:
+			err = s.WriteJSON(os.Stdout)
+		default:
+			err = fmt.Errorf("statistics can only be shown as %s or %s", todo.OutputTable, todo.OutputJSON)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+	case *export != "":
+		err := todos.Export(os.Stdout, *export)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+	case *importFormat != "":
+		imported, err := importFiles(*importFormat, flag.Args()...)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		n := todos.Merge(imported)
+		err = save(store, journal, hooks, fmt.Sprintf("import %d todos from %s", n, *importFormat), before, todos)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		fmt.Fprintf(os.Stdout, "imported %d todos\n", n)
+	case *encrypt, *decrypt, *changePassphrase:
+		archiveFile := todo.ArchivePath(path)
+		var archived *todo.Todos
+		if _, err := os.Stat(archiveFile); err == nil {
+			archived = &todo.Todos{}
+			if err := archived.Load(archiveFile); err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+		}
+
+		switch {
+		case *encrypt && encrypted:
+			err = errors.New("the list is already encrypted; use -change-passphrase")
+		case !*encrypt && !encrypted:
+			err = errors.New("the list is not encrypted")
+		case *decrypt:
+			todo.Passphrase = ""
+		default:
+			todo.Passphrase, err = readNewPassphrase()
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		// Everything was read with the old passphrase and is written
+		// again with the new one, or none.
+		if err := reseal(store, journal, todos, archived, archiveFile); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		// Backups made when the list was migrated hold it in the clear, or
+		// sealed with the old passphrase.
+		if todo.Passphrase != "" {
+			removed := 0
+			for _, name := range []string{path, archiveFile} {
+				n, err := todo.RemoveBackups(name)
+				removed += n
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err.Error())
+					os.Exit(1)
+				}
+			}
+			if removed > 0 {
+				fmt.Fprintf(os.Stdout, "removed %d backups of older versions of the list\n", removed)
+			}
+		}
+
+		switch {
+		case *encrypt:
+			fmt.Fprintln(os.Stdout, "encrypted the list")
+		case *decrypt:
+			fmt.Fprintln(os.Stdout, "decrypted the list")
+		default:
+			fmt.Fprintln(os.Stdout, "changed the passphrase")
+		}
+	case *undo, *redo:
+		step := journal.Undo
+		verb := "undid"
+		if *redo {
+			step = journal.Redo
+			verb = "redid"
+		}
+
+		op, err := step(&todos.Todos)
 		if err != nil {
 			fmt.Fprintln(os.Stderr, err.Error())
 			os.Exit(1)
 		}
-	case *complete > 0:
-		err := todos.Complete(*complete)
+
+		err = hooks.Pre(before, todos.Todos)
 		if err != nil {
 			fmt.Fprintln(os.Stderr, err.Error())
 			os.Exit(1)
 		}
 
-		err = todos.Store(todoFile)
+		// The todos an archive operation moves are written where they
+		// go first, as when archiving, so a failure never loses them.
+		if op.Archive && *redo {
+			if err := syncArchive(path, before, todos.Todos); err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+		}
+
+		err = store.Store(todos)
 		if err != nil {
 			fmt.Fprintln(os.Stderr, err.Error())
 			os.Exit(1)
 		}
-	case *delete > 0:
-		err := todos.Delete(*delete)
+
+		if op.Archive && *undo {
+			if err := syncArchive(path, before, todos.Todos); err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+		}
+
+		err = journal.Store()
 		if err != nil {
 			fmt.Fprintln(os.Stderr, err.Error())
 			os.Exit(1)
 		}
 
-		err = todos.Store(todoFile)
+		hooks.Post(before, todos.Todos)
+
+		fmt.Fprintf(os.Stdout, "%s: %s\n", verb, op.Description)
+	case *history:
+		journal.PrintHistory(historySize)
+	case *archive:
+		age, err := parseAge(*olderThan)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		archiveFile := todo.ArchivePath(path)
+		archivedTodos := &todo.Todos{}
+		if err := archivedTodos.Load(archiveFile); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		n := todos.Archive(archivedTodos, todo.Clock().Add(-age))
+		if n == 0 {
+			fmt.Fprintln(os.Stdout, "nothing to archive")
+			return
+		}
+
+		// The moved todos count as deleted for the hooks, which may refuse
+		// the change before anything is written.
+		if err := hooks.Pre(before, todos.Todos); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		// The archive is written first so a failure never loses todos.
+		if err := archivedTodos.Store(archiveFile); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		err = journal.RecordArchive(fmt.Sprintf("archive %d todos", n), before, todos.Todos)
+		if err == nil {
+			err = commit(store, journal, hooks, before, todos)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		fmt.Fprintf(os.Stdout, "archived %d todos\n", n)
+	case *archived:
+		filter, err := listFilter(*project, *tag, *from, *until)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		names, err := todo.ParseColumns(*columns)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		filter.Query, err = todo.ParseQuery(strings.Join(flag.Args(), " "))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		archivedTodos := &todo.Todos{}
+		if err := archivedTodos.Load(todo.ArchivePath(path)); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		if *sortBy != "" {
+			order, err := todo.ParseSort(*sortBy)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			archivedTodos.Sort(order)
+		}
+
+		err = archivedTodos.Output(os.Stdout, filter, *format, names)
 		if err != nil {
 			fmt.Fprintln(os.Stderr, err.Error())
 			os.Exit(1)
 		}
 	case *list:
-		todos.Print()
+		filter, err := listFilter(*project, *tag, *from, *until)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		names, err := todo.ParseColumns(*columns)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		filter.Query, err = todo.ParseQuery(strings.Join(flag.Args(), " "))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		if *sortBy != "" {
+			order, err := todo.ParseSort(*sortBy)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			todos.Sort(order)
+		}
+
+		err = todos.Output(os.Stdout, filter, *format, names)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
 	default:
 		fmt.Fprintln(os.Stdout, "invalid command")
 		os.Exit(0)
@@ -78,6 +759,256 @@ func main() {
 
 }
 
+func defaultBackend() string {
+	if backend := os.Getenv(storageEnv); backend != "" {
+		return backend
+	}
+
+	return todo.BackendJSON
+}
+
+func loadConfig() (todo.Config, error) {
+	path, err := todo.ConfigPath()
+	if err != nil {
+		return todo.Config{}, err
+	}
+
+	return todo.LoadConfig(path)
+}
+
+// dataDir finds the directory holding the lists and makes sure it exists.
+func dataDir(config todo.Config) (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return dir, os.MkdirAll(dir, 0755)
+}
+
+// moveLegacy moves the list older versions kept in the working directory to
+// path the first time, and points out one that is left behind after that.
+func moveLegacy(path string) error {
+	unlock, err := todo.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	moved, err := todo.MoveLegacy(todo.LegacyPath, path)
+	if err != nil {
+		return err
+	}
+	if moved {
+		fmt.Fprintf(os.Stderr, "moved %s to %s\n", todo.LegacyPath, path)
+	} else if _, err := os.Stat(todo.LegacyPath); err == nil {
+		fmt.Fprintf(os.Stderr, "%s in this directory is not used; todos are kept in %s\n", todo.LegacyPath, path)
+	}
+
+	return nil
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			list = append(list, part)
+		}
+	}
+
+	return list
+}
+
+// save stores the list and records the change from before in the journal,
+// running the hooks around it.
+func save(store todo.Storage, journal *todo.Journal, hooks *todo.Hooks, description string, before todo.Todos, todos *todo.List) error {
+	if err := hooks.Pre(before, todos.Todos); err != nil {
+		return err
+	}
+
+	if err := journal.Record(description, before, todos.Todos); err != nil {
+		return err
+	}
+
+	return commit(store, journal, hooks, before, todos)
+}
+
+// commit is save for a change the pre-hooks have accepted and the journal
+// has recorded already.
+func commit(store todo.Storage, journal *todo.Journal, hooks *todo.Hooks, before todo.Todos, todos *todo.List) error {
+	if err := store.Store(todos); err != nil {
+		return err
+	}
+
+	if err := journal.Store(); err != nil {
+		return err
+	}
+
+	hooks.Post(before, todos.Todos)
+
+	return nil
+}
+
+// syncArchive updates the archive of the list at path after an archive
+// operation turned the list from before into after.
+func syncArchive(path string, before, after todo.Todos) error {
+	archiveFile := todo.ArchivePath(path)
+	archived := &todo.Todos{}
+	if err := archived.Load(archiveFile); err != nil {
+		return err
+	}
+
+	todo.SyncArchive(archived, before, after)
+
+	return archived.Store(archiveFile)
+}
+
+// reseal stores the list, its journal and its archive, if there is one,
+// with the current passphrase.
+func reseal(store todo.Storage, journal *todo.Journal, todos *todo.List, archived *todo.Todos, archiveFile string) error {
+	if err := store.Store(todos); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(journal.Path); err == nil || len(journal.Operations) > 0 {
+		if err := journal.Store(); err != nil {
+			return err
+		}
+	}
+
+	if archived == nil {
+		return nil
+	}
+
+	return archived.Store(archiveFile)
+}
+
+// readPassphrase takes the passphrase from the environment variable env,
+// or prompts for it on the terminal.
+func readPassphrase(env, prompt string) (string, error) {
+	if passphrase := os.Getenv(env); passphrase != "" {
+		return passphrase, nil
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("the list is encrypted; set $%s or run todo in a terminal to enter the passphrase", env)
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	passphrase, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+
+	return string(passphrase), nil
+}
+
+// readNewPassphrase takes a new passphrase from the environment, or prompts
+// for it twice.
+func readNewPassphrase() (string, error) {
+	if passphrase := os.Getenv(newPassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("set $%s or run todo in a terminal to enter the new passphrase", newPassphraseEnv)
+	}
+
+	passphrase, err := readPassphrase(newPassphraseEnv, "New passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", errors.New("empty passphrase is not allowed")
+	}
+
+	again, err := readPassphrase(newPassphraseEnv, "Repeat the new passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if again != passphrase {
+		return "", errors.New("the passphrases do not match")
+	}
+
+	return passphrase, nil
+}
+
+// preview lists the todos a bulk command would change.
+func preview(todos *todo.Todos, ids []int, verb, format, columns string) error {
+	names, err := todo.ParseColumns(columns)
+	if err != nil {
+		return err
+	}
+
+	if format == todo.OutputTable {
+		fmt.Fprintf(os.Stdout, "would %s %d todos:\n", verb, len(ids))
+	}
+
+	picked := todos.Pick(ids)
+	return picked.Output(os.Stdout, todo.Filter{}, format, names)
+}
+
+// formatIDs writes IDs for the journal, e.g. "1, 3, 5".
+func formatIDs(ids []int) string {
+	parts := make([]string, len(ids))
+	for idx, id := range ids {
+		parts[idx] = strconv.Itoa(id)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// mergeFiles merges the changes from base to theirs into ours, writing the
+// result to ours the way git expects of a merge driver. Conflicting changes
+// are returned; ours is written with our side of them.
+func mergeFiles(files ...string) ([]todo.Conflict, error) {
+	if len(files) != 3 {
+		return nil, errors.New("merge needs three files: base, ours and theirs")
+	}
+
+	// The files are read without Load so that git's temporary copies are
+	// not backed up when they are in an older format.
+	var lists [3]todo.List
+	for idx, name := range files {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		if lists[idx], err = todo.DecodeList(data); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	merged, conflicts := todo.MergeLists(lists[0], lists[1], lists[2])
+
+	return conflicts, merged.Store(files[1])
+}
+
+// importFiles reads todos from the named files, or from stdin if there are none.
+func importFiles(format string, files ...string) (todo.Todos, error) {
+	if len(files) == 0 {
+		return todo.Import(os.Stdin, format)
+	}
+
+	all := todo.Todos{}
+	for _, name := range files {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+
+		imported, err := todo.Import(f, format)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		all = append(all, imported...)
+	}
+
+	return all, nil
+}
+
 func getInput(r io.Reader, args ...string) (string, error) {
 	if len(args) > 0 {
 		return strings.Join(args, " "), nil
@@ -92,8 +1023,55 @@ func getInput(r io.Reader, args ...string) (string, error) {
 	text := scanner.Text()
 
 	if len(text) == 0 {
-		return "", errors.New("empty todo is not allowed")
+		return "", todo.ErrEmpty
 	}
 
 	return text, nil
 }
+
+func listFilter(project, tag, from, until string) (todo.Filter, error) {
+	fromTime, untilTime, err := dateRange(from, until)
+	if err != nil {
+		return todo.Filter{}, err
+	}
+
+	return todo.Filter{
+		Projects:      splitList(project),
+		Tags:          splitList(tag),
+		CompletedFrom: fromTime,
+		CompletedTo:   untilTime,
+	}, nil
+}
+
+// dateRange parses the -from and -until dates; an empty one stays zero.
+func dateRange(from, until string) (fromTime, untilTime time.Time, err error) {
+	now := todo.Clock()
+	if from != "" {
+		if fromTime, err = todo.ParseDate(from, now); err != nil {
+			return
+		}
+	}
+	if until != "" {
+		untilTime, err = todo.ParseDate(until, now)
+	}
+
+	return
+}
+
+// parseAge reads a duration such as "30d", "12h" or "1h30m".
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	age, err := time.ParseDuration(s)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+
+	return age, nil
+}
@@ -1,6 +1,11 @@
 package todo
 
-import "fmt"
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/term"
+)
 
 const (
 	ColorDefault = "\x1b[39m"
@@ -9,20 +14,45 @@ const (
 	ColorGreen = "\x1b[32m"
 	ColorBlue  = "\x1b[94m"
 	ColorGray  = "\x1b[90m"
+
+	StyleReverse      = "\x1b[7m"
+	StyleReverseReset = "\x1b[27m"
 )
 
+// Colors turns the colored output on or off.
+var Colors = true
+
+// UseColors reports whether output to f should be colored: only on a
+// terminal, and not if $NO_COLOR is set (see https://no-color.org).
+func UseColors(f *os.File) bool {
+	return os.Getenv("NO_COLOR") == "" && term.IsTerminal(int(f.Fd()))
+}
+
+// colored wraps s in the given color if colors are on.
+func colored(color, s string) string {
+	if !Colors {
+		return s
+	}
+
+	return fmt.Sprintf("%s%s%s", color, s, ColorDefault)
+}
+
 func red(s string) string {
-	return fmt.Sprintf("%s%s%s", ColorRed, s, ColorDefault)
+	return colored(ColorRed, s)
 }
 
 func green(s string) string {
-	return fmt.Sprintf("%s%s%s", ColorGreen, s, ColorDefault)
+	return colored(ColorGreen, s)
 }
 
 func blue(s string) string {
-	return fmt.Sprintf("%s%s%s", ColorBlue, s, ColorDefault)
+	return colored(ColorBlue, s)
 }
 
 func gray(s string) string {
-	return fmt.Sprintf("%s%s%s", ColorGray, s, ColorDefault)
+	return colored(ColorGray, s)
+}
+
+func reverse(s string) string {
+	return fmt.Sprintf("%s%s%s", StyleReverse, s, StyleReverseReset)
 }
@@ -0,0 +1,278 @@
+package todo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alexeyco/simpletable"
+)
+
+// DataDirEnv names the environment variable that overrides the data directory.
+const DataDirEnv = "TODO_DIR"
+
+// DefaultList is the list used when no other is named.
+const DefaultList = "todos"
+
+// Config holds the settings read from the config file.
+type Config struct {
+	DataDir    string
+	PrettyJSON bool
+	// HooksDir holds the hooks, by default the hooks directory next to
+	// the config file. HookTimeout limits how long each runs, e.g. "30s".
+	HooksDir    string
+	HookTimeout string
+}
+
+// ConfigPath is where the config file is looked for, under the user's
+// config directory ($XDG_CONFIG_HOME/todo/config.json on Linux).
+func ConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "todo", "config.json"), nil
+}
+
+// LoadConfig reads the config file at path. A missing file is an empty config.
+func LoadConfig(path string) (Config, error) {
+	var c Config
+
+	file, err := ioutil.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return c, nil
+		}
+		return c, err
+	}
+
+	if len(file) == 0 {
+		return c, nil
+	}
+	if err := json.Unmarshal(file, &c); err != nil {
+		return c, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return c, nil
+}
+
+// Dir returns the directory the lists are kept in: $TODO_DIR if it is set,
+// then DataDir from the config, then $XDG_DATA_HOME/todo, which defaults to
+// ~/.local/share/todo.
+func (c Config) Dir() (string, error) {
+	if dir := os.Getenv(DataDirEnv); dir != "" {
+		return dir, nil
+	}
+	if c.DataDir != "" {
+		return c.DataDir, nil
+	}
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "todo"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".local", "share", "todo"), nil
+}
+
+// LegacyPath is where the todos were kept, in the working directory, before
+// the data directory.
+const LegacyPath = ".todos.json"
+
+// MoveLegacy moves the list at legacy, with its journal and archive, to path
+// unless there already is a list there. It reports whether it moved one.
+// The files are copied rather than renamed, as the data directory is often
+// on another file system than the working directory.
+func MoveLegacy(legacy, path string) (bool, error) {
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+	if _, err := os.Stat(legacy); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	// The list itself goes last, so that a move cut short is tried again.
+	suffixes := []string{".journal", ".archive", ""}
+	for _, suffix := range suffixes {
+		data, err := ioutil.ReadFile(legacy + suffix)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		if err := writeFileAtomic(path+suffix, data, 0644); err != nil {
+			return false, err
+		}
+	}
+	for _, suffix := range suffixes {
+		if err := os.Remove(legacy + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return true, err
+		}
+	}
+
+	return true, nil
+}
+
+// Hooks returns the hooks the config sets up.
+func (c Config) Hooks() (*Hooks, error) {
+	h := &Hooks{Dir: c.HooksDir, Timeout: DefaultHookTimeout}
+
+	if h.Dir == "" {
+		path, err := ConfigPath()
+		if err != nil {
+			return nil, err
+		}
+		h.Dir = filepath.Join(filepath.Dir(path), "hooks")
+	}
+
+	if c.HookTimeout != "" {
+		timeout, err := time.ParseDuration(c.HookTimeout)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid hook timeout %q", c.HookTimeout)
+		}
+		h.Timeout = timeout
+	}
+
+	return h, nil
+}
+
+// backendExtensions maps the file extension of a list to its storage backend.
+var backendExtensions = map[string]string{
+	".json": BackendJSON,
+	".db":   BackendSQLite,
+}
+
+// ListPath is the file the named list is kept in with the given backend.
+func ListPath(dir, name, backend string) (string, error) {
+	if name == "" || strings.HasPrefix(name, ".") || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid list name %q", name)
+	}
+
+	switch backend {
+	case BackendJSON, "":
+		return filepath.Join(dir, name+".json"), nil
+	case BackendSQLite:
+		return filepath.Join(dir, name+".db"), nil
+	default:
+		return "", fmt.Errorf("unknown storage backend %q (use %s or %s)", backend, BackendJSON, BackendSQLite)
+	}
+}
+
+// ListInfo describes one of the lists found in the data directory.
+type ListInfo struct {
+	Name    string
+	Backend string
+	Pending int
+	// Encrypted is set for lists that could not be read without the
+	// passphrase; their Pending count is unknown.
+	Encrypted bool
+}
+
+// Lists finds the lists kept in dir and counts their pending todos.
+func Lists(dir string) ([]ListInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var lists []ListInfo
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		backend, ok := backendExtensions[ext]
+		if !ok || entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		store, err := NewStorage(backend, filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		todos := &List{}
+		if err := store.Load(todos); errors.Is(err, ErrPassphrase) {
+			lists = append(lists, ListInfo{
+				Name:      strings.TrimSuffix(entry.Name(), ext),
+				Backend:   backend,
+				Encrypted: true,
+			})
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+
+		lists = append(lists, ListInfo{
+			Name:    strings.TrimSuffix(entry.Name(), ext),
+			Backend: backend,
+			Pending: todos.CountPending(),
+		})
+	}
+
+	sort.Slice(lists, func(a, b int) bool {
+		if lists[a].Name != lists[b].Name {
+			return lists[a].Name < lists[b].Name
+		}
+		return lists[a].Backend < lists[b].Backend
+	})
+
+	return lists, nil
+}
+
+// PrintLists prints the lists with their pending counts, marking current.
+func PrintLists(lists []ListInfo, current string) {
+
+	table := simpletable.New()
+
+	table.Header = &simpletable.Header{
+		Cells: []*simpletable.Cell{
+			{Align: simpletable.AlignCenter, Text: "List"},
+			{Align: simpletable.AlignCenter, Text: "Storage"},
+			{Align: simpletable.AlignRight, Text: "Pending"},
+		},
+	}
+
+	var cells [][]*simpletable.Cell
+
+	total := 0
+	for _, l := range lists {
+		name := blue(l.Name)
+		if l.Name == current {
+			name = green("* " + l.Name)
+		}
+		pending := fmt.Sprintf("%d", l.Pending)
+		if l.Encrypted {
+			pending = gray("encrypted")
+		}
+		cells = append(cells, []*simpletable.Cell{
+			{Text: name},
+			{Text: l.Backend},
+			{Align: simpletable.AlignRight, Text: pending},
+		})
+		total += l.Pending
+	}
+
+	table.Body = &simpletable.Body{Cells: cells}
+
+	table.Footer = &simpletable.Footer{Cells: []*simpletable.Cell{
+		{Align: simpletable.AlignCenter, Span: 3, Text: red(fmt.Sprintf("You have %d pending todos in %d lists", total, len(lists)))},
+	}}
+
+	table.SetStyle(simpletable.StyleUnicode)
+
+	table.Println()
+}
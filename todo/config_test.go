@@ -0,0 +1,229 @@
+package todo_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/example/todo"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	c, err := todo.LoadConfig(filepath.Join(dir, "missing.json"))
+	if err != nil || c != (todo.Config{}) {
+		t.Errorf("missing file: got %+v, %v", c, err)
+	}
+
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{"DataDir":"/data","PrettyJSON":true,"HookTimeout":"5s"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err = todo.LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (todo.Config{DataDir: "/data", PrettyJSON: true, HookTimeout: "5s"}); c != want {
+		t.Errorf("got %+v, want %+v", c, want)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"DataDir":`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := todo.LoadConfig(path); err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("got %v, want an error naming %s", err, path)
+	}
+}
+
+func TestConfigDir(t *testing.T) {
+	t.Setenv(todo.DataDirEnv, "")
+	t.Setenv("XDG_DATA_HOME", "/xdg")
+
+	tests := []struct {
+		env    string
+		config todo.Config
+		want   string
+	}{
+		{"/env", todo.Config{DataDir: "/config"}, "/env"},
+		{"", todo.Config{DataDir: "/config"}, "/config"},
+		{"", todo.Config{}, filepath.Join("/xdg", "todo")},
+	}
+
+	for _, tt := range tests {
+		t.Setenv(todo.DataDirEnv, tt.env)
+		if got, err := tt.config.Dir(); err != nil || got != tt.want {
+			t.Errorf("env %q, config %+v: got %q, %v, want %q", tt.env, tt.config, got, err, tt.want)
+		}
+	}
+}
+
+func TestMoveLegacy(t *testing.T) {
+	dir := t.TempDir()
+	legacy := filepath.Join(dir, ".todos.json")
+	path := filepath.Join(dir, "data", "todos.json")
+	if err := os.Mkdir(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	l := &todo.List{}
+	l.Add("from the old place")
+	if err := l.Store(legacy); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(todo.JournalPath(legacy), []byte(`{}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	moved, err := todo.MoveLegacy(legacy, path)
+	if err != nil || !moved {
+		t.Fatalf("got %v, %v, want a move", moved, err)
+	}
+	loaded := &todo.List{}
+	if err := loaded.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Todos) != 1 || loaded.Todos[0].Task != "from the old place" {
+		t.Errorf("got %+v", loaded.Todos)
+	}
+	if _, err := os.Stat(todo.JournalPath(path)); err != nil {
+		t.Errorf("journal not moved: %v", err)
+	}
+	for _, p := range []string{legacy, todo.JournalPath(legacy)} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("%s left behind: %v", p, err)
+		}
+	}
+
+	// Once the data directory has a list, another legacy file is left alone.
+	if err := l.Store(legacy); err != nil {
+		t.Fatal(err)
+	}
+	if moved, err := todo.MoveLegacy(legacy, path); err != nil || moved {
+		t.Errorf("got %v, %v, want no move", moved, err)
+	}
+	if _, err := os.Stat(legacy); err != nil {
+		t.Errorf("legacy file gone: %v", err)
+	}
+}
+
+func listsDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	home := &todo.List{}
+	home.Add("water plants")
+	home.Add("fix bike")
+	if err := home.Complete(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := home.Store(filepath.Join(dir, "home.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	work := &todo.List{}
+	work.Add("review budget")
+	if err := (&todo.SQLite{Path: filepath.Join(dir, "work.db")}).Store(work); err != nil {
+		t.Fatal(err)
+	}
+
+	// Neither of these is a list.
+	for _, name := range []string{".hidden.json", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestLists(t *testing.T) {
+	lists, err := todo.Lists(listsDir(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []todo.ListInfo{
+		{Name: "home", Backend: todo.BackendJSON, Pending: 1},
+		{Name: "work", Backend: todo.BackendSQLite, Pending: 1},
+	}
+	if !reflect.DeepEqual(lists, want) {
+		t.Errorf("got %+v, want %+v", lists, want)
+	}
+
+	if lists, err := todo.Lists(filepath.Join(t.TempDir(), "missing")); err != nil || lists != nil {
+		t.Errorf("missing dir: got %+v, %v", lists, err)
+	}
+}
+
+func TestPrintLists(t *testing.T) {
+	t.Cleanup(func() { todo.Colors = true })
+	todo.Colors = false
+
+	lists := []todo.ListInfo{
+		{Name: "home", Backend: todo.BackendJSON, Pending: 1},
+		{Name: "secret", Backend: todo.BackendJSON, Encrypted: true},
+		{Name: "work", Backend: todo.BackendSQLite, Pending: 2},
+	}
+	got := captureStdout(t, func() { todo.PrintLists(lists, "work") })
+
+	for _, want := range []string{"home", "encrypted", "* work", "You have 3 pending todos in 3 lists"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output lacks %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "* home") {
+		t.Errorf("home marked as current:\n%s", got)
+	}
+}
+
+// captureStdout returns what fn writes to os.Stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	done := make(chan string)
+	go func() {
+		out, _ := io.ReadAll(r)
+		done <- string(out)
+	}()
+	fn()
+	w.Close()
+
+	return <-done
+}
+
+func TestListsWritesNothing(t *testing.T) {
+	dir := t.TempDir()
+	old := `{"Version":4,"Todos":[{"ID":1,"Task":"one"}]}`
+	if err := os.WriteFile(filepath.Join(dir, "old.json"), []byte(old), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := todo.Lists(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		for _, e := range entries {
+			t.Errorf("found %s", e.Name())
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "old.json")); string(data) != old {
+		t.Errorf("old.json changed to %s", data)
+	}
+}
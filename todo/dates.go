@@ -0,0 +1,230 @@
+package todo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Clock tells the time that relative dates in queries and on the command
+// line are read against. Tests replace it to get fixed results.
+var Clock = time.Now
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thurs": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+// ParseDate reads a date relative to now. Besides YYYY-MM-DD and RFC 3339
+// it understands
+//
+//	now, today, tomorrow, yesterday
+//	eod                      the end of today
+//	friday, next friday      the next Friday after today
+//	last friday              the last Friday before today
+//	in 3 days, 2 weeks ago   also hours, minutes, months and years
+//
+// A day may be followed by a time such as 9am, 5:30pm, 17:00 or noon, as
+// in "tomorrow 9am" or "next friday at 14:00"; a time alone is today.
+// Days without a time start at midnight in the location of now.
+func ParseDate(s string, now time.Time) (time.Time, error) {
+	t, _, err := parseDate(s, now)
+
+	return t, err
+}
+
+// parseDate is ParseDate that also reports whether the date names a whole
+// day rather than a moment.
+func parseDate(s string, now time.Time) (time.Time, bool, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		return t, true, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, false, nil
+	}
+
+	invalid := fmt.Errorf("invalid date %q", s)
+
+	words := strings.Fields(strings.ToLower(s))
+	if len(words) == 0 {
+		return time.Time{}, false, invalid
+	}
+
+	switch strings.Join(words, " ") {
+	case "now":
+		return now, false, nil
+	case "eod":
+		return midnight(now).AddDate(0, 0, 1).Add(-time.Second), false, nil
+	}
+
+	day, whole, rest, ok := parseDay(words, now)
+	if !ok {
+		day, whole, rest = midnight(now), true, words
+	}
+	if len(rest) > 0 && rest[0] == "at" {
+		rest = rest[1:]
+		if len(rest) == 0 {
+			return time.Time{}, false, invalid
+		}
+	}
+
+	switch {
+	case len(rest) == 0 && ok:
+		return day, whole, nil
+	case len(rest) == 1 && whole:
+		hour, minute, ok := parseClock(rest[0])
+		if !ok {
+			return time.Time{}, false, invalid
+		}
+		y, m, d := day.Date()
+		return time.Date(y, m, d, hour, minute, 0, 0, day.Location()), false, nil
+	default:
+		return time.Time{}, false, invalid
+	}
+}
+
+// parseDay reads the day at the start of words, returning it, whether it
+// is a whole day and the words left. Amounts of hours and minutes give a
+// moment instead of a day.
+func parseDay(words []string, now time.Time) (time.Time, bool, []string, bool) {
+	today := midnight(now)
+
+	switch words[0] {
+	case "today":
+		return today, true, words[1:], true
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), true, words[1:], true
+	case "yesterday":
+		return today.AddDate(0, 0, -1), true, words[1:], true
+	case "next", "last":
+		if len(words) < 2 {
+			return time.Time{}, false, nil, false
+		}
+		wd, ok := weekdays[words[1]]
+		if !ok {
+			return time.Time{}, false, nil, false
+		}
+		if words[0] == "last" {
+			days := (int(today.Weekday())-int(wd)+6)%7 + 1
+			return today.AddDate(0, 0, -days), true, words[2:], true
+		}
+		return nextWeekday(today, wd), true, words[2:], true
+	case "in":
+		if len(words) < 3 {
+			return time.Time{}, false, nil, false
+		}
+		t, whole, ok := addAmount(now, words[1], words[2], 1)
+		return t, whole, words[3:], ok
+	}
+
+	if wd, ok := weekdays[words[0]]; ok {
+		return nextWeekday(today, wd), true, words[1:], true
+	}
+	if len(words) >= 3 && words[2] == "ago" {
+		t, whole, ok := addAmount(now, words[0], words[1], -1)
+		return t, whole, words[3:], ok
+	}
+
+	return time.Time{}, false, nil, false
+}
+
+// addAmount adds n units to now, or takes them away if sign is -1. Days
+// and longer units give a whole day, shorter ones a moment.
+func addAmount(now time.Time, n, unit string, sign int) (time.Time, bool, bool) {
+	amount, err := strconv.Atoi(n)
+	if n == "a" || n == "an" {
+		amount, err = 1, nil
+	}
+	if err != nil || amount < 0 {
+		return time.Time{}, false, false
+	}
+	amount *= sign
+
+	switch strings.TrimSuffix(unit, "s") {
+	case "minute", "min":
+		return now.Add(time.Duration(amount) * time.Minute), false, true
+	case "hour":
+		return now.Add(time.Duration(amount) * time.Hour), false, true
+	case "day":
+		return midnight(now).AddDate(0, 0, amount), true, true
+	case "week":
+		return midnight(now).AddDate(0, 0, 7*amount), true, true
+	case "month":
+		return midnight(now).AddDate(0, amount, 0), true, true
+	case "year":
+		return midnight(now).AddDate(amount, 0, 0), true, true
+	default:
+		return time.Time{}, false, false
+	}
+}
+
+// parseClock reads a time of day such as 9am, 5:30pm, 17:00, noon or
+// midnight.
+func parseClock(s string) (hour, minute int, ok bool) {
+	switch s {
+	case "noon":
+		return 12, 0, true
+	case "midnight":
+		return 0, 0, true
+	}
+
+	s, pm := strings.CutSuffix(s, "pm")
+	s, am := strings.CutSuffix(s, "am")
+	if am && pm {
+		return 0, 0, false
+	}
+
+	h, m, colon := strings.Cut(s, ":")
+	if !colon && !am && !pm {
+		// A bare number could be anything.
+		return 0, 0, false
+	}
+
+	hour, err := strconv.Atoi(h)
+	if err != nil {
+		return 0, 0, false
+	}
+	if colon {
+		if len(m) != 2 {
+			return 0, 0, false
+		}
+		if minute, err = strconv.Atoi(m); err != nil || minute < 0 || minute > 59 {
+			return 0, 0, false
+		}
+	}
+
+	if am || pm {
+		if hour < 1 || hour > 12 {
+			return 0, 0, false
+		}
+		hour %= 12
+		if pm {
+			hour += 12
+		}
+	}
+	if hour < 0 || hour > 23 {
+		return 0, 0, false
+	}
+
+	return hour, minute, true
+}
+
+// nextWeekday is the first day after today that falls on wd.
+func nextWeekday(today time.Time, wd time.Weekday) time.Time {
+	days := (int(wd)-int(today.Weekday())+6)%7 + 1
+
+	return today.AddDate(0, 0, days)
+}
+
+func midnight(t time.Time) time.Time {
+	y, m, d := t.Date()
+
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,130 @@
+package todo_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/example/todo"
+)
+
+func TestParseDate(t *testing.T) {
+	// A Wednesday afternoon.
+	now := time.Date(2026, 10, 14, 15, 4, 5, 0, time.UTC)
+	day := func(d int) time.Time {
+		return time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC)
+	}
+	at := func(d, hour, minute int) time.Time {
+		return time.Date(2026, 10, d, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2026-11-02", time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC)},
+		{"2026-11-02T10:30:00Z", time.Date(2026, 11, 2, 10, 30, 0, 0, time.UTC)},
+		{"now", now},
+		{"today", day(14)},
+		{"Today", day(14)},
+		{"tomorrow", day(15)},
+		{"yesterday", day(13)},
+		{"eod", time.Date(2026, 10, 14, 23, 59, 59, 0, time.UTC)},
+		{"tomorrow 9am", at(15, 9, 0)},
+		{"tomorrow at 9am", at(15, 9, 0)},
+		{"tomorrow 5:30pm", at(15, 17, 30)},
+		{"tomorrow 17:45", at(15, 17, 45)},
+		{"today noon", at(14, 12, 0)},
+		{"today 12am", at(14, 0, 0)},
+		{"today 12pm", at(14, 12, 0)},
+		{"9am", at(14, 9, 0)},
+		{"at 18:00", at(14, 18, 0)},
+		{"friday", day(16)},
+		{"next friday", day(16)},
+		{"next fri 8am", at(16, 8, 0)},
+		{"next wednesday", day(21)},
+		{"last friday", day(9)},
+		{"last wednesday", day(7)},
+		{"last thursday", day(8)},
+		{"in 3 days", day(17)},
+		{"in 1 day", day(15)},
+		{"in a week", day(21)},
+		{"in 2 weeks 10am", at(28, 10, 0)},
+		{"in 1 month", time.Date(2026, 11, 14, 0, 0, 0, 0, time.UTC)},
+		{"in 2 hours", now.Add(2 * time.Hour)},
+		{"in 90 minutes", now.Add(90 * time.Minute)},
+		{"3 days ago", day(11)},
+		{"  in   3   days ", day(17)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := todo.ParseDate(tt.in, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDateInvalid(t *testing.T) {
+	now := time.Date(2026, 10, 14, 15, 4, 5, 0, time.UTC)
+
+	for _, in := range []string{
+		"",
+		"soon",
+		"next",
+		"next week",
+		"in days",
+		"in 3 fortnights",
+		"in -3 days",
+		"tomorrow 9",
+		"tomorrow 13pm",
+		"tomorrow 9:7",
+		"tomorrow 24:00",
+		"tomorrow at",
+		"tomorrow 9am sharp",
+		"in 2 hours 9am",
+		"eod tomorrow",
+		"2026-13-01",
+	} {
+		t.Run(in, func(t *testing.T) {
+			if got, err := todo.ParseDate(in, now); err == nil {
+				t.Errorf("got %s, want an error", got)
+			}
+		})
+	}
+}
+
+func TestQueryRelativeDate(t *testing.T) {
+	now := time.Date(2026, 10, 14, 15, 4, 5, 0, time.UTC)
+	todo.Clock = func() time.Time { return now }
+	t.Cleanup(func() { todo.Clock = time.Now })
+
+	todos := todo.Todos{}
+	todos.SetDue(todos.Add("today"), now)
+	todos.SetDue(todos.Add("friday"), now.AddDate(0, 0, 2))
+	todos.SetDue(todos.Add("next week"), now.AddDate(0, 0, 7))
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"due:today", []string{"today"}},
+		{`due<"next friday"`, []string{"today"}},
+		{`due<="next friday"`, []string{"today", "friday"}},
+		{`due>"in 3 days"`, []string{"next week"}},
+	}
+
+	for _, tt := range tests {
+		q, err := todo.ParseQuery(tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := tasks(todos.Query(q)); !equal(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package todo
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Passphrase, when set, makes Store and the journal encrypt the files they
+// write. Encrypted files can only be loaded while it is set to the
+// passphrase they were written with.
+var Passphrase string
+
+var ErrPassphrase = errors.New("the list is encrypted and the passphrase is missing or wrong")
+
+const (
+	kdfScrypt = "scrypt"
+
+	// The scrypt cost recommended for interactive logins.
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// Sealed is data encrypted with AES-256-GCM under a key derived from the
+// passphrase with scrypt, using the parameters stored alongside.
+type Sealed struct {
+	KDF   string
+	N     int
+	R     int
+	P     int
+	Salt  []byte
+	Nonce []byte
+	Data  []byte
+}
+
+// keys caches derived keys, as deriving one takes a noticeable moment and
+// a command reads and writes several files.
+var keys = struct {
+	sync.Mutex
+	byInput map[string][]byte
+	// last is reused for writing, with its salt, as long as the
+	// passphrase is still the one it was made with. A new passphrase
+	// gets a new salt.
+	last           *Sealed
+	lastPassphrase string
+}{byInput: map[string][]byte{}}
+
+func deriveKey(passphrase string, s *Sealed) ([]byte, error) {
+	if s.KDF != kdfScrypt {
+		return nil, fmt.Errorf("unknown key derivation %q", s.KDF)
+	}
+
+	id := fmt.Sprintf("%s\x00%x\x00%d\x00%d\x00%d", passphrase, s.Salt, s.N, s.R, s.P)
+
+	keys.Lock()
+	defer keys.Unlock()
+
+	if key, ok := keys.byInput[id]; ok {
+		return key, nil
+	}
+
+	key, err := scrypt.Key([]byte(passphrase), s.Salt, s.N, s.R, s.P, 32)
+	if err != nil {
+		return nil, err
+	}
+	keys.byInput[id] = key
+
+	return key, nil
+}
+
+// seal encrypts plain with Passphrase.
+func seal(plain []byte) (*Sealed, error) {
+	keys.Lock()
+	last := keys.last
+	if keys.lastPassphrase != Passphrase {
+		last = nil
+	}
+	keys.Unlock()
+
+	s := &Sealed{KDF: kdfScrypt, N: scryptN, R: scryptR, P: scryptP}
+	if last != nil {
+		s.Salt = last.Salt
+	} else {
+		s.Salt = make([]byte, 16)
+		if _, err := rand.Read(s.Salt); err != nil {
+			return nil, err
+		}
+	}
+
+	key, err := deriveKey(Passphrase, s)
+	if err != nil {
+		return nil, err
+	}
+	keys.Lock()
+	keys.last = &Sealed{KDF: s.KDF, N: s.N, R: s.R, P: s.P, Salt: s.Salt}
+	keys.lastPassphrase = Passphrase
+	keys.Unlock()
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(s.Nonce); err != nil {
+		return nil, err
+	}
+	s.Data = gcm.Seal(nil, s.Nonce, plain, nil)
+
+	return s, nil
+}
+
+// open decrypts s with Passphrase.
+func (s *Sealed) open() ([]byte, error) {
+	if Passphrase == "" {
+		return nil, ErrPassphrase
+	}
+
+	key, err := deriveKey(Passphrase, s)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.Nonce) != gcm.NonceSize() {
+		return nil, errors.New("invalid nonce in encrypted data")
+	}
+
+	plain, err := gcm.Open(nil, s.Nonce, s.Data, nil)
+	if err != nil {
+		return nil, ErrPassphrase
+	}
+
+	// Write with the same salt, so the key need not be derived again.
+	keys.Lock()
+	if keys.last == nil || keys.lastPassphrase != Passphrase {
+		keys.last = &Sealed{KDF: s.KDF, N: s.N, R: s.R, P: s.P, Salt: s.Salt}
+		keys.lastPassphrase = Passphrase
+	}
+	keys.Unlock()
+
+	return plain, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// IsEncrypted reports whether the list in the file at path is encrypted.
+func IsEncrypted(path string) (bool, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	sealed, err := isSealed(data)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return sealed, nil
+}
+
+// isSealed reports whether data is a list in encrypted form.
+func isSealed(data []byte) (bool, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '{' {
+		return false, nil
+	}
+
+	var e struct{ Sealed *Sealed }
+	if err := json.Unmarshal(data, &e); err != nil {
+		return false, err
+	}
+
+	return e.Sealed != nil, nil
+}
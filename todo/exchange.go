@@ -0,0 +1,490 @@
+package todo
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FormatTodoTxt  = "todotxt"
+	FormatCSV      = "csv"
+	FormatMarkdown = "markdown"
+)
+
+const todoTxtDate = "2006-01-02"
+
+var errUnknownFormat = errors.New("unknown format (use todotxt, csv, markdown or ical)")
+
+// Export writes the list, subtasks included, to w in the given format.
+func (t *Todos) Export(w io.Writer, format string) error {
+	switch format {
+	case FormatTodoTxt:
+		return t.exportTodoTxt(w)
+	case FormatCSV:
+		return t.exportCSV(w)
+	case FormatMarkdown:
+		return t.exportMarkdown(w)
+	case FormatICal:
+		return t.exportICal(w)
+	default:
+		return fmt.Errorf("%q: %w", format, errUnknownFormat)
+	}
+}
+
+// Import reads todos written in the given format. The returned todos have
+// no IDs yet; pass them to Merge to add them to a list.
+func Import(r io.Reader, format string) (Todos, error) {
+	switch format {
+	case FormatTodoTxt:
+		return importTodoTxt(r)
+	case FormatCSV:
+		return importCSV(r)
+	case FormatMarkdown:
+		return importMarkdown(r)
+	case FormatICal:
+		return importICal(r)
+	default:
+		return nil, fmt.Errorf("%q: %w", format, errUnknownFormat)
+	}
+}
+
+// Merge adds the imported todos under fresh IDs and returns how many were
+// added. A todo with the same text and done state as one already in the same
+// place is skipped, though its subtasks are still merged.
+func (t *Todos) Merge(imported Todos) int {
+	return mergeInto(t, imported, t.nextID)
+}
+
+func mergeInto(ls *Todos, imported Todos, next func() int) int {
+	added := 0
+	for _, i := range imported {
+		if idx := ls.duplicateOf(i); idx >= 0 {
+			added += mergeInto(&(*ls)[idx].Children, i.Children, next)
+			continue
+		}
+
+		children := i.Children
+		i.Children = nil
+		i.ID = next()
+		*ls = append(*ls, i)
+		added++
+		added += mergeInto(&(*ls)[len(*ls)-1].Children, children, next)
+	}
+
+	return added
+}
+
+// duplicateOf finds the todo in t that i repeats. Tags are compared apart
+// from the text, as a todo.txt export writes those set with -project and
+// -tag into it.
+func (t *Todos) duplicateOf(i item) int {
+	for idx, existing := range *t {
+		if existing.Done == i.Done && untagged(existing.Task) == untagged(i.Task) &&
+			sameTags(existing.Projects, i.Projects) && sameTags(existing.Tags, i.Tags) {
+			return idx
+		}
+	}
+
+	return -1
+}
+
+// newImported builds a todo read from another tool, with tags taken from its text.
+func newImported(task string, done bool) item {
+	projects, tags := parseTags(task)
+	return item{
+		Task:     task,
+		Done:     done,
+		Projects: projects,
+		Tags:     tags,
+	}
+}
+
+var todoTxtPriorities = map[Priority]string{
+	PriorityHigh:   "A",
+	PriorityMedium: "B",
+	PriorityLow:    "C",
+}
+
+// exportTodoTxt writes one line per todo following the todo.txt format.
+// Subtasks become ordinary lines since todo.txt has no hierarchy.
+func (t *Todos) exportTodoTxt(w io.Writer) error {
+	var err error
+	t.walk(func(_ int, i *item) {
+		if err != nil {
+			return
+		}
+
+		var words []string
+		if i.Done {
+			words = append(words, "x")
+			if !i.CompletedAt.IsZero() {
+				words = append(words, i.CompletedAt.Format(todoTxtDate))
+			}
+		} else if p, ok := todoTxtPriorities[i.Priority]; ok {
+			words = append(words, "("+p+")")
+		}
+		if !i.CreatedAt.IsZero() {
+			words = append(words, i.CreatedAt.Format(todoTxtDate))
+		}
+
+		words = append(words, i.Task)
+
+		textProjects, textTags := parseTags(i.Task)
+		for _, p := range i.Projects {
+			if !hasTag(textProjects, p) {
+				words = append(words, "+"+p)
+			}
+		}
+		for _, tag := range i.Tags {
+			if !hasTag(textTags, tag) {
+				words = append(words, "@"+tag)
+			}
+		}
+		if p, ok := todoTxtPriorities[i.Priority]; ok && i.Done {
+			words = append(words, "pri:"+p)
+		}
+		if !i.DueAt.IsZero() {
+			words = append(words, "due:"+i.DueAt.Format(todoTxtDate))
+		}
+
+		_, err = fmt.Fprintln(w, strings.Join(words, " "))
+	})
+
+	return err
+}
+
+func parseTodoTxtPriority(s string) (Priority, bool) {
+	for p, letter := range todoTxtPriorities {
+		if s == letter {
+			return p, true
+		}
+	}
+
+	return PriorityNone, false
+}
+
+func importTodoTxt(r io.Reader) (Todos, error) {
+	ls := Todos{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		words := strings.Fields(scanner.Text())
+		if len(words) == 0 {
+			continue
+		}
+
+		done := false
+		var completedAt, createdAt time.Time
+		priority := PriorityNone
+
+		if words[0] == "x" {
+			done = true
+			words = words[1:]
+			if len(words) > 0 {
+				if d, err := time.ParseInLocation(todoTxtDate, words[0], time.Local); err == nil {
+					completedAt = d
+					words = words[1:]
+				}
+			}
+		} else if len(words[0]) == 3 && words[0][0] == '(' && words[0][2] == ')' {
+			if p, ok := parseTodoTxtPriority(words[0][1:2]); ok {
+				priority = p
+			} else {
+				priority = PriorityLow
+			}
+			words = words[1:]
+		}
+		if len(words) > 0 {
+			if d, err := time.ParseInLocation(todoTxtDate, words[0], time.Local); err == nil {
+				createdAt = d
+				words = words[1:]
+			}
+		}
+
+		var text []string
+		var dueAt time.Time
+		for _, word := range words {
+			switch {
+			case strings.HasPrefix(word, "due:"):
+				d, err := time.ParseInLocation(todoTxtDate, strings.TrimPrefix(word, "due:"), time.Local)
+				if err != nil {
+					return nil, fmt.Errorf("invalid due date in %q", scanner.Text())
+				}
+				dueAt = d
+			case strings.HasPrefix(word, "pri:"):
+				if p, ok := parseTodoTxtPriority(strings.TrimPrefix(word, "pri:")); ok {
+					priority = p
+				}
+			default:
+				text = append(text, word)
+			}
+		}
+		if len(text) == 0 {
+			continue
+		}
+
+		i := newImported(strings.Join(text, " "), done)
+		i.Priority = priority
+		i.CreatedAt = createdAt
+		i.CompletedAt = completedAt
+		i.DueAt = dueAt
+		if i.CreatedAt.IsZero() {
+			i.CreatedAt = time.Now()
+		}
+		ls = append(ls, i)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return ls, nil
+}
+
+var csvHeader = []string{"ID", "Parent", "Task", "Done", "Priority", "Due", "Projects", "Tags", "CreatedAt", "CompletedAt"}
+
+func formatCSVTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+func parseCSVTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339, s)
+}
+
+func (t *Todos) exportCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	var err error
+	var walk func(ls Todos, parent int)
+	walk = func(ls Todos, parent int) {
+		for _, i := range ls {
+			if err != nil {
+				return
+			}
+
+			parentID := ""
+			if parent > 0 {
+				parentID = strconv.Itoa(parent)
+			}
+			err = cw.Write([]string{
+				strconv.Itoa(i.ID),
+				parentID,
+				i.Task,
+				strconv.FormatBool(i.Done),
+				i.Priority.String(),
+				formatCSVTime(i.DueAt),
+				strings.Join(i.Projects, " "),
+				strings.Join(i.Tags, " "),
+				formatCSVTime(i.CreatedAt),
+				formatCSVTime(i.CompletedAt),
+			})
+			walk(i.Children, i.ID)
+		}
+	}
+	walk(*t, 0)
+	if err != nil {
+		return err
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// importCSV reads rows in the layout written by exportCSV. The ID and Parent
+// columns only rebuild the subtask tree, in any order of the rows; merged
+// todos get new IDs.
+func importCSV(r io.Reader) (Todos, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return Todos{}, nil
+	}
+
+	columns := map[string]int{}
+	for idx, name := range records[0] {
+		columns[name] = idx
+	}
+	if _, ok := columns["Task"]; !ok {
+		return nil, errors.New("csv is missing the Task column")
+	}
+	field := func(record []string, name string) string {
+		if idx, ok := columns[name]; ok && idx < len(record) {
+			return strings.TrimSpace(record[idx])
+		}
+		return ""
+	}
+
+	type row struct {
+		item   item
+		id     string
+		parent string
+	}
+	var rows []row
+	for n, record := range records[1:] {
+		task := field(record, "Task")
+		if task == "" {
+			continue
+		}
+
+		done, _ := strconv.ParseBool(field(record, "Done"))
+		i := newImported(task, done)
+
+		if i.Priority, err = ParsePriority(field(record, "Priority")); err != nil {
+			return nil, fmt.Errorf("row %d: %w", n+2, err)
+		}
+		if i.DueAt, err = parseCSVTime(field(record, "Due")); err != nil {
+			return nil, fmt.Errorf("row %d: %w", n+2, err)
+		}
+		if i.CreatedAt, err = parseCSVTime(field(record, "CreatedAt")); err != nil {
+			return nil, fmt.Errorf("row %d: %w", n+2, err)
+		}
+		if i.CompletedAt, err = parseCSVTime(field(record, "CompletedAt")); err != nil {
+			return nil, fmt.Errorf("row %d: %w", n+2, err)
+		}
+		if i.CreatedAt.IsZero() {
+			i.CreatedAt = time.Now()
+		}
+		for _, p := range strings.Fields(field(record, "Projects")) {
+			i.Projects = appendTag(i.Projects, p)
+		}
+		for _, tag := range strings.Fields(field(record, "Tags")) {
+			i.Tags = appendTag(i.Tags, tag)
+		}
+
+		rows = append(rows, row{item: i, id: field(record, "ID"), parent: field(record, "Parent")})
+	}
+
+	items := make(Todos, len(rows))
+	ids := make([]string, len(rows))
+	parents := make([]string, len(rows))
+	for idx, r := range rows {
+		items[idx], ids[idx], parents[idx] = r.item, r.id, r.parent
+	}
+
+	return importTree(items, ids, parents), nil
+}
+
+// importTree puts imported todos into a tree the way a merge does, in any
+// order, where the todo items[n] is known as ids[n] and names its parent as
+// parents[n]. Todos naming an unknown parent, themselves or a parent in a
+// cycle end up at the top level rather than lost.
+func importTree(items Todos, ids, parents []string) Todos {
+	keys := map[string]int{}
+	for idx, id := range ids {
+		if _, ok := keys[id]; id != "" && !ok {
+			keys[id] = idx + 1
+		}
+	}
+	nodes := map[int]node{}
+	order := make([]int, len(items))
+	for idx, i := range items {
+		i.ID = idx + 1
+		nodes[idx+1] = node{item: i, parent: keys[parents[idx]]}
+		order[idx] = idx + 1
+	}
+
+	ls := buildTree(nodes, order)
+	ls.walk(func(_ int, i *item) { i.ID = 0 })
+
+	return ls
+}
+
+// exportMarkdown writes a GitHub-style checklist, indenting subtasks.
+func (t *Todos) exportMarkdown(w io.Writer) error {
+	var err error
+	t.walk(func(depth int, i *item) {
+		if err != nil {
+			return
+		}
+
+		box := "[ ]"
+		if i.Done {
+			box = "[x]"
+		}
+		_, err = fmt.Fprintf(w, "%s- %s %s\n", strings.Repeat("  ", depth), box, i.Task)
+	})
+
+	return err
+}
+
+// importMarkdown reads "- [ ]" and "- [x]" lines. Deeper indented items
+// become subtasks of the item above them; other lines are ignored.
+func importMarkdown(r io.Reader) (Todos, error) {
+	ls := Todos{}
+
+	type level struct {
+		indent int
+		list   *Todos
+	}
+	stack := []level{{indent: -1, list: &ls}}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.ReplaceAll(scanner.Text(), "\t", "    ")
+		trimmed := strings.TrimLeft(line, " ")
+		indent := len(line) - len(trimmed)
+
+		var done bool
+		var task string
+		switch {
+		case hasAnyPrefix(trimmed, "- [ ] ", "* [ ] "):
+			task = trimmed[6:]
+		case hasAnyPrefix(trimmed, "- [x] ", "* [x] ", "- [X] ", "* [X] "):
+			done = true
+			task = trimmed[6:]
+		default:
+			continue
+		}
+		task = strings.TrimSpace(task)
+		if task == "" {
+			continue
+		}
+
+		for len(stack) > 1 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+
+		i := newImported(task, done)
+		i.CreatedAt = time.Now()
+		if done {
+			i.CompletedAt = i.CreatedAt
+		}
+
+		parent := stack[len(stack)-1].list
+		*parent = append(*parent, i)
+		stack = append(stack, level{indent: indent, list: &(*parent)[len(*parent)-1].Children})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return ls, nil
+}
+
+func hasAnyPrefix(s string, prefixes ...string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,205 @@
+package todo_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/example/todo"
+)
+
+// titles writes a tree of imported todos, which have no IDs, as
+// "a, b (c)", marking done ones with an x.
+func titles(todos todo.Todos) string {
+	var parts []string
+	for _, i := range todos {
+		part := i.Task
+		if i.Done {
+			part = "x " + part
+		}
+		if len(i.Children) > 0 {
+			part += " (" + titles(i.Children) + ")"
+		}
+		parts = append(parts, part)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func TestImportTodoTxt(t *testing.T) {
+	in := `x 2026-01-05 2026-01-01 file taxes +home pri:A due:2026-04-15
+(B) 2026-01-02 call Bob @phone due:2026-02-01
+
+(Z) something odd
+plain task
+`
+	todos, err := todo.Import(strings.NewReader(in), todo.FormatTodoTxt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := titles(todos), "x file taxes +home, call Bob @phone, something odd, plain task"; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+
+	day := func(m time.Month, d int) time.Time { return time.Date(2026, m, d, 0, 0, 0, 0, time.Local) }
+	taxes, call := todos[0], todos[1]
+	if taxes.Priority != todo.PriorityHigh || !taxes.CompletedAt.Equal(day(1, 5)) || !taxes.CreatedAt.Equal(day(1, 1)) || !taxes.DueAt.Equal(day(4, 15)) {
+		t.Errorf("got %+v", taxes)
+	}
+	if !equal(taxes.Projects, []string{"home"}) {
+		t.Errorf("got projects %v", taxes.Projects)
+	}
+	if call.Priority != todo.PriorityMedium || !call.DueAt.Equal(day(2, 1)) || !equal(call.Tags, []string{"phone"}) {
+		t.Errorf("got %+v", call)
+	}
+	if todos[2].Priority != todo.PriorityLow {
+		t.Errorf("unknown priority letter read as %s", todos[2].Priority)
+	}
+
+	if _, err := todo.Import(strings.NewReader("task due:soon"), todo.FormatTodoTxt); err == nil {
+		t.Error("expected an error for an invalid due date")
+	}
+}
+
+func TestImportCSV(t *testing.T) {
+	const header = "ID,Parent,Task,Done\n"
+
+	tests := []struct {
+		name string
+		rows string
+		want string
+	}{
+		{"flat", "1,,a,false\n2,,b,true\n", "a, x b"},
+		{"subtasks", "1,,a,false\n2,1,b,false\n3,2,c,false\n4,1,d,false\n", "a (b (c), d)"},
+		{"subtask before its parent", "3,2,c,false\n2,1,b,false\n1,,a,false\n", "a (b (c))"},
+		{"unknown parent", "1,9,a,false\n", "a"},
+		{"own parent", "1,1,self,false\n2,1,sub,false\n", "self (sub)"},
+		{"cycle", "1,2,a,false\n2,1,b,false\n3,,c,false\n", "c, a (b)"},
+		{"rows without a task", "1,,a,false\n2,,,false\n", "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			todos, err := todo.Import(strings.NewReader(header+tt.rows), todo.FormatCSV)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := titles(todos); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := todo.Import(strings.NewReader("ID,Text\n1,a\n"), todo.FormatCSV); err == nil {
+		t.Error("expected an error for a missing Task column")
+	}
+	if _, err := todo.Import(strings.NewReader("Task,Priority\na,urgent\n"), todo.FormatCSV); err == nil {
+		t.Error("expected an error for an invalid priority")
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	todos := todo.Todos{}
+	a := todos.Add("a +proj")
+	todos.SetPriority(a, todo.PriorityHigh)
+	b, _ := todos.AddSub(a, "b")
+	todos.AddSub(b, "c @ctx")
+	todos.Complete(todos.Add("d"))
+
+	var buf bytes.Buffer
+	if err := todos.Export(&buf, todo.FormatCSV); err != nil {
+		t.Fatal(err)
+	}
+	imported, err := todo.Import(&buf, todo.FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := titles(imported), "a +proj (b (c @ctx)), x d"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if imported[0].Priority != todo.PriorityHigh || !equal(imported[0].Projects, []string{"proj"}) {
+		t.Errorf("got %+v", imported[0])
+	}
+}
+
+func TestImportMarkdown(t *testing.T) {
+	in := `# Release
+
+- [ ] ship it
+  - [x] write the changelog
+  - [ ] tag
+	- [ ] push the tag
+* [X] book the room
+- [ ]
+Some notes.
+`
+	todos, err := todo.Import(strings.NewReader(in), todo.FormatMarkdown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := titles(todos), "ship it (x write the changelog, tag (push the tag)), x book the room"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestMergeImported(t *testing.T) {
+	todos := todo.Todos{}
+	ship := todos.Add("ship it")
+	todos.AddSub(ship, "write the changelog")
+	todos.Add("book the room")
+
+	imported, err := todo.Import(strings.NewReader(`- [ ] ship it
+  - [ ] write the changelog
+  - [ ] tag
+- [x] book the room
+- [ ] new
+`), todo.FormatMarkdown)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Known todos are skipped, but their new subtasks are merged; a todo
+	// done on one side only is not the same todo.
+	if n := todos.Merge(imported); n != 3 {
+		t.Errorf("merged %d todos, want 3", n)
+	}
+	if got, want := render(todos), "1 ship it (2 write the changelog, 4 tag), 3 book the room, 5 book the room, 6 new"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	if n := todos.Merge(imported); n != 0 {
+		t.Errorf("merging again added %d todos", n)
+	}
+}
+
+func TestTodoTxtMergeBack(t *testing.T) {
+	todos := todo.Todos{}
+	todos.Add("buy milk")
+	todos.AddTags(todos.Add("call Bob @phone"), []string{"work"}, []string{"urgent"})
+	todos.AddTags(todos.Add("file taxes +home"), []string{"home"}, nil)
+	todos.Complete(todos.Add("pack"))
+
+	var buf bytes.Buffer
+	if err := todos.Export(&buf, todo.FormatTodoTxt); err != nil {
+		t.Fatal(err)
+	}
+	imported, err := todo.Import(&buf, todo.FormatTodoTxt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := todos.Merge(imported); n != 0 {
+		t.Errorf("merging the export back added %d todos: %s", n, render(todos))
+	}
+
+	// Tags still tell todos apart.
+	imported, err = todo.Import(strings.NewReader("buy milk +home\n"), todo.FormatTodoTxt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := todos.Merge(imported); n != 1 {
+		t.Errorf("merged %d todos, want 1", n)
+	}
+}
@@ -0,0 +1,231 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// FormatVersion is the version of the file format Store writes. The formats
+// so far are:
+//
+//  1. a bare JSON array of todos without IDs, as written by the first releases
+//  2. a bare JSON array of todos with IDs and, optionally, subtasks
+//  3. an object holding the Version and the Todos
+//  4. the same object, which may hold the todos encrypted as Sealed instead
+//  5. the same object with the NextID new todos are numbered from
+const FormatVersion = 5
+
+// nextIDVersion is the first format that keeps NextID.
+const nextIDVersion = 5
+
+// envelope is the file format from version 3 on.
+type envelope struct {
+	Version int
+	NextID  int     `json:",omitempty"`
+	Todos   Todos   `json:",omitempty"`
+	Sealed  *Sealed `json:",omitempty"`
+}
+
+// migrations[v-1] turns a file in format v into format v+1.
+var migrations = []func(data []byte) ([]byte, error){
+	migrateAddIDs,
+	migrateEnvelope,
+	migrateVersion(4),
+	migrateVersion(5),
+}
+
+// formatVersion works out which format data is in.
+func formatVersion(data []byte) (int, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return FormatVersion, nil
+	}
+
+	if data[0] == '[' {
+		var todos []map[string]json.RawMessage
+		if err := json.Unmarshal(data, &todos); err != nil {
+			return 0, err
+		}
+		for _, i := range todos {
+			if _, ok := i["ID"]; !ok {
+				return 1, nil
+			}
+		}
+		return 2, nil
+	}
+
+	var e struct{ Version int }
+	if err := json.Unmarshal(data, &e); err != nil {
+		return 0, err
+	}
+	if e.Version < 3 {
+		return 0, fmt.Errorf("invalid format version %d", e.Version)
+	}
+
+	return e.Version, nil
+}
+
+// migrateAddIDs numbers the todos of a format 1 file, which had no subtasks.
+func migrateAddIDs(data []byte) ([]byte, error) {
+	var todos []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &todos); err != nil {
+		return nil, err
+	}
+
+	for idx, i := range todos {
+		i["ID"] = json.RawMessage(fmt.Sprint(idx + 1))
+	}
+
+	return json.Marshal(todos)
+}
+
+// migrateEnvelope wraps the array of a format 2 file.
+func migrateEnvelope(data []byte) ([]byte, error) {
+	return json.Marshal(struct {
+		Version int
+		Todos   json.RawMessage
+	}{3, data})
+}
+
+// migrateVersion only sets the version, for formats that merely added to
+// the one before.
+func migrateVersion(version int) func(data []byte) ([]byte, error) {
+	return func(data []byte) ([]byte, error) {
+		var e map[string]json.RawMessage
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, err
+		}
+		e["Version"] = json.RawMessage(fmt.Sprint(version))
+
+		return json.Marshal(e)
+	}
+}
+
+// Decode reads a list in any of the file formats, migrating older ones.
+func Decode(data []byte) (Todos, error) {
+	l, err := DecodeList(data)
+
+	return l.Todos, err
+}
+
+// DecodeList is Decode keeping the NextID of the list. For files in a
+// format without it, it follows the highest ID in the list.
+func DecodeList(data []byte) (List, error) {
+	version, err := formatVersion(data)
+	if err != nil {
+		return List{}, err
+	}
+	if version > FormatVersion {
+		return List{}, fmt.Errorf("the list is in format %d, but this version of todo only reads up to format %d; please upgrade", version, FormatVersion)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return List{Todos: Todos{}, NextID: 1}, nil
+	}
+
+	for v := version; v < FormatVersion; v++ {
+		if data, err = migrations[v-1](data); err != nil {
+			return List{}, fmt.Errorf("migrating from format %d: %w", v, err)
+		}
+	}
+
+	var e envelope
+	if err := json.Unmarshal(data, &e); err != nil {
+		return List{}, err
+	}
+	if e.Sealed != nil {
+		plain, err := e.Sealed.open()
+		if err != nil {
+			return List{}, err
+		}
+		if err := json.Unmarshal(plain, &e.Todos); err != nil {
+			return List{}, err
+		}
+	}
+	if e.Todos == nil {
+		e.Todos = Todos{}
+	}
+	e.Todos.assignIDs()
+
+	l := List{Todos: e.Todos, NextID: e.NextID}
+	l.NextID = l.next()
+
+	return l, nil
+}
+
+// Encode writes the list in the current file format, indented if PrettyJSON
+// is set and encrypted if Passphrase is.
+func (t *Todos) Encode() ([]byte, error) {
+	return (&List{Todos: *t}).Encode()
+}
+
+// Encode writes the list with its NextID in the current file format.
+func (l *List) Encode() ([]byte, error) {
+	t := &l.Todos
+	e := envelope{Version: FormatVersion, NextID: l.next(), Todos: *t}
+	if Passphrase != "" {
+		plain, err := json.Marshal(t)
+		if err != nil {
+			return nil, err
+		}
+		if e.Sealed, err = seal(plain); err != nil {
+			return nil, err
+		}
+		e.Todos = nil
+	}
+
+	if PrettyJSON {
+		data, err := json.MarshalIndent(e, "", "  ")
+		return append(data, '\n'), err
+	}
+
+	return json.Marshal(e)
+}
+
+// BackupPath is where Load keeps a copy of a file in format version before
+// migrating it.
+func BackupPath(path string, version int) string {
+	return fmt.Sprintf("%s.v%d.bak", path, version)
+}
+
+// backup copies the original file before it is migrated, unless a copy
+// was made before. While Passphrase is set, todos are never copied in the
+// clear.
+func backup(path string, data []byte) error {
+	version, err := formatVersion(data)
+	if err != nil || version >= FormatVersion {
+		return err
+	}
+	if Passphrase != "" {
+		if sealed, err := isSealed(data); err != nil || !sealed {
+			return err
+		}
+	}
+
+	dest := BackupPath(path, version)
+	if _, err := os.Stat(dest); err == nil || !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return writeFileAtomic(dest, data, 0644)
+}
+
+// RemoveBackups deletes the copies backup made of the file at path and
+// returns how many there were.
+func RemoveBackups(path string) (int, error) {
+	n := 0
+	for version := 1; version < FormatVersion; version++ {
+		err := os.Remove(BackupPath(path, version))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	return n, nil
+}
@@ -0,0 +1,223 @@
+package todo_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/example/todo"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestFormatGolden loads a file in every format the list was ever stored in
+// and checks that it is stored again as testdata/<name>.golden.
+func TestFormatGolden(t *testing.T) {
+	todo.PrettyJSON = true
+	t.Cleanup(func() { todo.PrettyJSON = false })
+
+	tests := []struct {
+		name    string
+		version int
+	}{
+		{"format1", 1},
+		{"format1-priority", 1},
+		{"format2", 2},
+		{"format3", 3},
+		{"format4", 4},
+		{"format5", 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original, err := os.ReadFile(filepath.Join("testdata", tt.name+".json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			path := filepath.Join(t.TempDir(), "todos.json")
+			if err := os.WriteFile(path, original, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			todos := &todo.List{}
+			if err := todos.Load(path); err != nil {
+				t.Fatal(err)
+			}
+			if err := todos.Store(path); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", tt.name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("stored file differs from %s:\n%s", golden, got)
+			}
+
+			// Older files are backed up before they are replaced.
+			backup, err := os.ReadFile(todo.BackupPath(path, tt.version))
+			switch {
+			case tt.version == todo.FormatVersion && err == nil:
+				t.Errorf("file in the current format was backed up")
+			case tt.version < todo.FormatVersion && err != nil:
+				t.Errorf("no backup: %v", err)
+			case tt.version < todo.FormatVersion && !bytes.Equal(backup, original):
+				t.Errorf("backup differs from the original file")
+			}
+
+			// Loading the migrated file gives the same list again.
+			again := &todo.Todos{}
+			if err := again.Load(path); err != nil {
+				t.Fatal(err)
+			}
+			if !equal(tasks(*again), tasks(todos.Todos)) {
+				t.Errorf("reloaded %v, want %v", tasks(*again), tasks(todos.Todos))
+			}
+		})
+	}
+}
+
+func TestFormatEncrypted(t *testing.T) {
+	t.Cleanup(func() { todo.Passphrase = "" })
+
+	path := filepath.Join(t.TempDir(), "todos.json")
+	todos := &todo.Todos{}
+	todos.Add("call Acme Corp")
+
+	todo.Passphrase = "correct horse"
+	if err := todos.Store(path); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("Acme")) {
+		t.Fatalf("task text stored in the clear: %s", data)
+	}
+	if encrypted, err := todo.IsEncrypted(path); err != nil || !encrypted {
+		t.Errorf("IsEncrypted = %v, %v, want true", encrypted, err)
+	}
+
+	for _, passphrase := range []string{"", "wrong"} {
+		todo.Passphrase = passphrase
+		if err := (&todo.Todos{}).Load(path); !errors.Is(err, todo.ErrPassphrase) {
+			t.Errorf("loading with passphrase %q: got error %v, want ErrPassphrase", passphrase, err)
+		}
+	}
+
+	todo.Passphrase = "correct horse"
+	loaded := &todo.Todos{}
+	if err := loaded.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if !equal(tasks(*loaded), []string{"call Acme Corp"}) {
+		t.Errorf("got %v", tasks(*loaded))
+	}
+}
+
+func TestFormatEncryptedBackups(t *testing.T) {
+	t.Cleanup(func() { todo.Passphrase = "" })
+
+	original, err := os.ReadFile(filepath.Join("testdata", "format3.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "todos.json")
+	if err := os.WriteFile(path, original, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// An older file replaced while encrypting is not copied in the clear.
+	todo.Passphrase = "correct horse"
+	todos := &todo.List{}
+	if err := todos.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := todos.Store(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(todo.BackupPath(path, 3)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("plaintext backup written while encrypting: %v", err)
+	}
+
+	todo.Passphrase = ""
+	if err := os.WriteFile(path, original, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := todos.Store(path); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := todo.RemoveBackups(path); err != nil || n != 1 {
+		t.Errorf("RemoveBackups = %d, %v, want 1 backup removed", n, err)
+	}
+	if _, err := os.Stat(todo.BackupPath(path, 3)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("backup left behind: %v", err)
+	}
+}
+
+func TestFormatChangePassphrase(t *testing.T) {
+	t.Cleanup(func() { todo.Passphrase = "" })
+
+	path := filepath.Join(t.TempDir(), "todos.json")
+	salt := func() string {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var file struct{ Sealed todo.Sealed }
+		if err := json.Unmarshal(data, &file); err != nil {
+			t.Fatal(err)
+		}
+		return string(file.Sealed.Salt)
+	}
+
+	todos := &todo.List{}
+	todos.Add("call Acme Corp")
+	todo.Passphrase = "correct horse"
+	if err := todos.Store(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := todos.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	old := salt()
+
+	// The key for a new passphrase is not derived with the old salt.
+	todo.Passphrase = "battery staple"
+	if err := todos.Store(path); err != nil {
+		t.Fatal(err)
+	}
+	if salt() == old {
+		t.Error("new passphrase used with the old salt")
+	}
+	if err := todos.Load(path); err != nil {
+		t.Errorf("loading with the new passphrase: %v", err)
+	}
+}
+
+func TestFormatNewer(t *testing.T) {
+	_, err := todo.Decode([]byte(`{"Version": 99, "Todos": []}`))
+	if err == nil || !strings.Contains(err.Error(), "upgrade") {
+		t.Errorf("got error %v, want a request to upgrade", err)
+	}
+}
@@ -0,0 +1,176 @@
+package todo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	EventAdd      = "add"
+	EventComplete = "complete"
+	EventReopen   = "reopen"
+	EventEdit     = "edit"
+	EventDelete   = "delete"
+)
+
+// DefaultHookTimeout is how long a hook may run unless configured otherwise.
+const DefaultHookTimeout = 10 * time.Second
+
+// Hooks runs the executables in Dir when todos change. An executable named
+// after the stage and event, such as pre-add or post-complete, or starting
+// with that name and a dot, such as post-complete.chat.sh, runs for every
+// todo the change affects and gets the todo as JSON on stdin, the event as
+// its argument and in $TODO_EVENT.
+//
+// Pre-hooks run before a change is stored; if one fails or runs longer than
+// Timeout the change is refused. Post-hooks run once it is stored, and their
+// failures are only reported. The output of hooks goes to Output, or to
+// stderr if it is nil. A nil *Hooks runs nothing.
+type Hooks struct {
+	Dir     string
+	Timeout time.Duration
+	Output  io.Writer
+}
+
+// Change is a todo affected by a change of the list, and how.
+type Change struct {
+	Event string
+	Item  item
+}
+
+// Changes works out how each todo changed from before to after. Subtasks
+// are reported on their own, without their children.
+func Changes(before, after Todos) []Change {
+	old, _ := flatten(before)
+	current, order := flatten(after)
+
+	var changes []Change
+	for _, id := range order {
+		n := current[id]
+		prev, ok := old[id]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Event: EventAdd, Item: n.item})
+		case n.item.Done && !prev.item.Done:
+			changes = append(changes, Change{Event: EventComplete, Item: n.item})
+		case !n.item.Done && prev.item.Done:
+			changes = append(changes, Change{Event: EventReopen, Item: n.item})
+		case !n.equal(prev):
+			changes = append(changes, Change{Event: EventEdit, Item: n.item})
+		}
+	}
+
+	_, oldOrder := flatten(before)
+	for _, id := range oldOrder {
+		if _, ok := current[id]; !ok {
+			changes = append(changes, Change{Event: EventDelete, Item: old[id].item})
+		}
+	}
+
+	return changes
+}
+
+// Pre runs the pre-hooks for the change from before to after and returns
+// the first failure.
+func (h *Hooks) Pre(before, after Todos) error {
+	if h == nil {
+		return nil
+	}
+
+	for _, c := range Changes(before, after) {
+		if err := h.run("pre", c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Post runs the post-hooks for the change from before to after.
+func (h *Hooks) Post(before, after Todos) {
+	if h == nil {
+		return
+	}
+
+	for _, c := range Changes(before, after) {
+		if err := h.run("post", c); err != nil {
+			fmt.Fprintln(h.output(), err.Error())
+		}
+	}
+}
+
+func (h *Hooks) output() io.Writer {
+	if h.Output == nil {
+		return os.Stderr
+	}
+
+	return h.Output
+}
+
+func (h *Hooks) run(stage string, c Change) error {
+	name := stage + "-" + c.Event
+
+	entries, err := os.ReadDir(h.Dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	var input []byte
+	for _, entry := range entries {
+		if entry.Name() != name && !strings.HasPrefix(entry.Name(), name+".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() || (runtime.GOOS != "windows" && info.Mode()&0111 == 0) {
+			continue
+		}
+
+		if input == nil {
+			if input, err = json.Marshal(c.Item); err != nil {
+				return err
+			}
+		}
+		if err := h.exec(filepath.Join(h.Dir, entry.Name()), c.Event, input); err != nil {
+			return fmt.Errorf("%s hook %s for todo %d failed: %w", name, entry.Name(), c.Item.ID, err)
+		}
+	}
+
+	return nil
+}
+
+func (h *Hooks) exec(path, event string, input []byte) error {
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = DefaultHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path, event)
+	cmd.Env = append(os.Environ(), "TODO_EVENT="+event)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = h.output()
+	cmd.Stderr = h.output()
+	// Do not wait on children of the hook that keep its output open.
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+
+	return err
+}
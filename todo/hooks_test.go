@@ -0,0 +1,164 @@
+package todo_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/example/todo"
+)
+
+func TestChanges(t *testing.T) {
+	before := decode(t, `[
+		{"ID":1,"Task":"add tests"},
+		{"ID":2,"Task":"ship","Done":true},
+		{"ID":3,"Task":"fix bug","Children":[{"ID":4,"Task":"find it"}]},
+		{"ID":5,"Task":"old"}]`)
+	after := decode(t, `[
+		{"ID":1,"Task":"add tests","Done":true},
+		{"ID":2,"Task":"ship"},
+		{"ID":3,"Task":"fix the bug","Children":[{"ID":4,"Task":"find it"},{"ID":6,"Task":"write a test"}]}]`)
+
+	var got []string
+	for _, c := range todo.Changes(before, after) {
+		got = append(got, c.Event+" "+c.Item.Task)
+	}
+	want := []string{"complete add tests", "reopen ship", "edit fix the bug", "add write a test", "delete old"}
+	if !equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if changes := todo.Changes(before, before); len(changes) != 0 {
+		t.Errorf("got changes %v for an unchanged list", changes)
+	}
+}
+
+// writeHook puts an executable shell script into dir.
+func writeHook(t *testing.T, dir, name, script string) {
+	t.Helper()
+
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newHookList(t *testing.T) (*todo.SharedList, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks are shell scripts")
+	}
+
+	dir := t.TempDir()
+	hooks := filepath.Join(dir, "hooks")
+	if err := os.Mkdir(hooks, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "todos.json")
+	list := todo.NewSharedList(&todo.JSONFile{Path: path}, path)
+	list.Hooks = &todo.Hooks{Dir: hooks, Output: &bytes.Buffer{}}
+
+	return list, hooks
+}
+
+func TestHooksInput(t *testing.T) {
+	list, dir := newHookList(t)
+	out := filepath.Join(dir, "out")
+	writeHook(t, dir, "post-add.save.sh", `cat > "`+out+`.json"; echo "$1 $TODO_EVENT" > "`+out+`.args"`)
+	// Files that are not executable are no hooks.
+	if err := os.WriteFile(filepath.Join(dir, "post-add.txt"), []byte("exit 1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := list.Update(func(l *todo.List) (string, error) {
+		return "add", l.SetPriority(l.Add("write hooks +todo"), todo.PriorityHigh)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(out + ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		ID       int
+		Task     string
+		Priority string
+		Projects []string
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("%v: %s", err, data)
+	}
+	if got.ID != 1 || got.Task != "write hooks +todo" || got.Priority != "high" || !equal(got.Projects, []string{"todo"}) {
+		t.Errorf("hook got %s", data)
+	}
+
+	args, err := os.ReadFile(out + ".args")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(args)); got != "add add" {
+		t.Errorf("hook got arguments and $TODO_EVENT %q", got)
+	}
+}
+
+func TestHooksPreRefuses(t *testing.T) {
+	list, dir := newHookList(t)
+	writeHook(t, dir, "pre-add", `grep -q secret && { echo "no secrets" >&2; exit 1; }; exit 0`)
+	writeHook(t, dir, "post-add", `exit 3`)
+
+	add := func(task string) error {
+		return list.Update(func(l *todo.List) (string, error) {
+			l.Add(task)
+			return "add", nil
+		})
+	}
+
+	if err := add("a secret"); err == nil || !strings.Contains(err.Error(), "pre-add") {
+		t.Errorf("got error %v, want the pre-add hook to fail", err)
+	}
+	if output := list.Hooks.Output.(*bytes.Buffer).String(); !strings.Contains(output, "no secrets") {
+		t.Errorf("hook output %q", output)
+	}
+
+	// A failing post-hook is only reported.
+	if err := add("public"); err != nil {
+		t.Fatal(err)
+	}
+	if output := list.Hooks.Output.(*bytes.Buffer).String(); !strings.Contains(output, "post-add hook post-add for todo 1 failed") {
+		t.Errorf("hook output %q", output)
+	}
+
+	var got []string
+	list.View(func(t *todo.Todos) error {
+		got = tasks(*t)
+		return nil
+	})
+	if !equal(got, []string{"public"}) {
+		t.Errorf("stored %v, want only the todo the hook accepted", got)
+	}
+}
+
+func TestHooksTimeout(t *testing.T) {
+	list, dir := newHookList(t)
+	list.Hooks.Timeout = 100 * time.Millisecond
+	writeHook(t, dir, "pre-add", "exec sleep 10")
+
+	start := time.Now()
+	err := list.Update(func(l *todo.List) (string, error) {
+		l.Add("slow")
+		return "add", nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "timed out after 100ms") {
+		t.Errorf("got error %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("the hook was not stopped, took %s", elapsed)
+	}
+}
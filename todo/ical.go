@@ -0,0 +1,301 @@
+package todo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const FormatICal = "ical"
+
+const (
+	icalDateTime = "20060102T150405Z"
+	icalLocal    = "20060102T150405"
+	icalDate     = "20060102"
+
+	// icalLineLimit is the longest content line RFC 5545 allows, in octets.
+	icalLineLimit = 75
+)
+
+var icalPriorities = map[Priority]int{
+	PriorityHigh:   1,
+	PriorityMedium: 5,
+	PriorityLow:    9,
+}
+
+func icalUID(id int) string {
+	return fmt.Sprintf("todo-%d@todo", id)
+}
+
+// exportICal writes the list as an iCalendar (RFC 5545) calendar with one
+// VTODO per todo. Subtasks point at their parent with RELATED-TO.
+func (t *Todos) exportICal(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	line := func(name, value string) {
+		writeICalLine(bw, name+":"+value)
+	}
+
+	now := time.Now().UTC().Format(icalDateTime)
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//example//todo//EN")
+
+	var walk func(ls Todos, parent int)
+	walk = func(ls Todos, parent int) {
+		for _, i := range ls {
+			line("BEGIN", "VTODO")
+			line("UID", icalUID(i.ID))
+			line("DTSTAMP", now)
+			line("SUMMARY", escapeICal(i.Task))
+			if i.Done {
+				line("STATUS", "COMPLETED")
+			} else {
+				line("STATUS", "NEEDS-ACTION")
+			}
+			if !i.CreatedAt.IsZero() {
+				line("CREATED", i.CreatedAt.UTC().Format(icalDateTime))
+			}
+			if !i.CompletedAt.IsZero() {
+				line("COMPLETED", i.CompletedAt.UTC().Format(icalDateTime))
+			}
+			if !i.DueAt.IsZero() {
+				line("DUE", i.DueAt.UTC().Format(icalDateTime))
+			}
+			if p, ok := icalPriorities[i.Priority]; ok {
+				line("PRIORITY", strconv.Itoa(p))
+			}
+			var categories []string
+			for _, p := range i.Projects {
+				categories = append(categories, escapeICal("+"+p))
+			}
+			for _, tag := range i.Tags {
+				categories = append(categories, escapeICal("@"+tag))
+			}
+			if len(categories) > 0 {
+				line("CATEGORIES", strings.Join(categories, ","))
+			}
+			if parent > 0 {
+				writeICalLine(bw, "RELATED-TO;RELTYPE=PARENT:"+icalUID(parent))
+			}
+			line("END", "VTODO")
+
+			walk(i.Children, i.ID)
+		}
+	}
+	walk(*t, 0)
+
+	line("END", "VCALENDAR")
+
+	return bw.Flush()
+}
+
+// writeICalLine writes one content line, folding it so no physical line is
+// longer than the RFC 5545 limit and never splitting a UTF-8 sequence.
+func writeICalLine(w *bufio.Writer, s string) {
+	limit := icalLineLimit
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && s[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.WriteString(s[:cut] + "\r\n ")
+		s = s[cut:]
+		// Continuation lines start with a space, which counts towards the limit.
+		limit = icalLineLimit - 1
+	}
+	w.WriteString(s + "\r\n")
+}
+
+var icalEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+func escapeICal(s string) string {
+	return icalEscaper.Replace(s)
+}
+
+func unescapeICal(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			switch s[i] {
+			case 'n', 'N':
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(s[i])
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+
+	return b.String()
+}
+
+// splitICalList splits a comma separated value, keeping escaped commas.
+func splitICalList(s string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case ',':
+			parts = append(parts, unescapeICal(s[start:i]))
+			start = i + 1
+		}
+	}
+
+	return append(parts, unescapeICal(s[start:]))
+}
+
+func parseICalTime(params map[string]string, value string) (time.Time, error) {
+	if params["VALUE"] == "DATE" || len(value) == len(icalDate) {
+		return time.ParseInLocation(icalDate, value, time.Local)
+	}
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse(icalDateTime, value)
+	}
+
+	loc := time.Local
+	if tzid, ok := params["TZID"]; ok {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+
+	return time.ParseInLocation(icalLocal, value, loc)
+}
+
+// unfoldICal joins folded content lines back together.
+func unfoldICal(r io.Reader) ([]string, error) {
+	var lines []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if len(text) > 0 && (text[0] == ' ' || text[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += text[1:]
+			continue
+		}
+		lines = append(lines, text)
+	}
+
+	return lines, scanner.Err()
+}
+
+// importICal reads every VTODO of an iCalendar file. RELATED-TO with a
+// parent relation rebuilds subtasks; all other components are ignored.
+func importICal(r io.Reader) (Todos, error) {
+	lines, err := unfoldICal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	type vtodo struct {
+		item   item
+		uid    string
+		parent string
+	}
+	var todos []vtodo
+	var current *vtodo
+
+	for n, text := range lines {
+		colon := strings.IndexByte(text, ':')
+		if colon < 0 {
+			continue
+		}
+
+		nameAndParams := strings.Split(text[:colon], ";")
+		name := strings.ToUpper(nameAndParams[0])
+		value := text[colon+1:]
+		params := map[string]string{}
+		for _, p := range nameAndParams[1:] {
+			if k, v, ok := strings.Cut(p, "="); ok {
+				params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+			}
+		}
+
+		if name == "BEGIN" && strings.EqualFold(value, "VTODO") {
+			current = &vtodo{}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		i := &current.item
+		switch name {
+		case "END":
+			if strings.EqualFold(value, "VTODO") {
+				if i.Task != "" {
+					if i.CreatedAt.IsZero() {
+						i.CreatedAt = time.Now()
+					}
+					todos = append(todos, *current)
+				}
+				current = nil
+			}
+		case "UID":
+			current.uid = value
+		case "SUMMARY":
+			imported := newImported(unescapeICal(value), i.Done)
+			i.Task = imported.Task
+			for _, p := range imported.Projects {
+				i.Projects = appendTag(i.Projects, p)
+			}
+			for _, tag := range imported.Tags {
+				i.Tags = appendTag(i.Tags, tag)
+			}
+		case "STATUS":
+			i.Done = strings.EqualFold(value, "COMPLETED")
+		case "CREATED", "COMPLETED", "DUE":
+			parsed, err := parseICalTime(params, value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid %s: %w", n+1, name, err)
+			}
+			switch name {
+			case "CREATED":
+				i.CreatedAt = parsed
+			case "COMPLETED":
+				i.CompletedAt = parsed
+			case "DUE":
+				i.DueAt = parsed
+			}
+		case "PRIORITY":
+			p, _ := strconv.Atoi(value)
+			switch {
+			case p >= 1 && p <= 4:
+				i.Priority = PriorityHigh
+			case p == 5:
+				i.Priority = PriorityMedium
+			case p >= 6 && p <= 9:
+				i.Priority = PriorityLow
+			}
+		case "CATEGORIES":
+			for _, c := range splitICalList(value) {
+				if strings.HasPrefix(c, "+") {
+					i.Projects = appendTag(i.Projects, c)
+				} else {
+					i.Tags = appendTag(i.Tags, c)
+				}
+			}
+		case "RELATED-TO":
+			if reltype, ok := params["RELTYPE"]; !ok || strings.EqualFold(reltype, "PARENT") {
+				current.parent = value
+			}
+		}
+	}
+
+	items := make(Todos, len(todos))
+	uids := make([]string, len(todos))
+	parents := make([]string, len(todos))
+	for idx, v := range todos {
+		items[idx], uids[idx], parents[idx] = v.item, v.uid, v.parent
+	}
+
+	return importTree(items, uids, parents), nil
+}
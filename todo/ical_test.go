@@ -0,0 +1,155 @@
+package todo_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/example/todo"
+)
+
+func TestICalRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.json")
+	dst := filepath.Join(dir, "dst.json")
+
+	due := time.Date(2026, 11, 2, 9, 30, 0, 0, time.UTC)
+
+	todos := &todo.Todos{}
+	release := todos.Add("ship the release, finally; really +release @work")
+	todos.SetPriority(release, todo.PriorityHigh)
+	todos.SetDue(release, due)
+	todos.AddSub(release, "write a changelog that is long enough to need folding across more than one iCalendar content line ✅")
+	done := todos.Add("eat")
+	todos.Complete(done)
+
+	if err := todos.Store(src); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := &todo.Todos{}
+	if err := loaded.Load(src); err != nil {
+		t.Fatal(err)
+	}
+
+	var ics bytes.Buffer
+	if err := loaded.Export(&ics, todo.FormatICal); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(ics.String(), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+	}
+
+	imported, err := todo.Import(&ics, todo.FormatICal)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roundTripped := &todo.Todos{}
+	if n := roundTripped.Merge(imported); n != 3 {
+		t.Fatalf("merged %d todos, want 3", n)
+	}
+	if err := roundTripped.Store(dst); err != nil {
+		t.Fatal(err)
+	}
+
+	got := &todo.Todos{}
+	if err := got.Load(dst); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*got) != len(*loaded) {
+		t.Fatalf("got %d todos, want %d", len(*got), len(*loaded))
+	}
+	for idx, want := range *loaded {
+		g := (*got)[idx]
+		if g.Task != want.Task {
+			t.Errorf("task %d: got %q, want %q", idx, g.Task, want.Task)
+		}
+		if g.Done != want.Done {
+			t.Errorf("task %d: got done %v, want %v", idx, g.Done, want.Done)
+		}
+		if g.Priority != want.Priority {
+			t.Errorf("task %d: got priority %v, want %v", idx, g.Priority, want.Priority)
+		}
+		if !g.DueAt.Equal(want.DueAt) {
+			t.Errorf("task %d: got due %v, want %v", idx, g.DueAt, want.DueAt)
+		}
+		if !g.CreatedAt.Equal(want.CreatedAt.Truncate(time.Second)) {
+			t.Errorf("task %d: got created %v, want %v", idx, g.CreatedAt, want.CreatedAt)
+		}
+		if !g.CompletedAt.Equal(want.CompletedAt.Truncate(time.Second)) {
+			t.Errorf("task %d: got completed %v, want %v", idx, g.CompletedAt, want.CompletedAt)
+		}
+		if !equal(g.Projects, want.Projects) || !equal(g.Tags, want.Tags) {
+			t.Errorf("task %d: got tags %v %v, want %v %v", idx, g.Projects, g.Tags, want.Projects, want.Tags)
+		}
+		if len(g.Children) != len(want.Children) {
+			t.Errorf("task %d: got %d subtasks, want %d", idx, len(g.Children), len(want.Children))
+		} else if len(g.Children) > 0 && g.Children[0].Task != want.Children[0].Task {
+			t.Errorf("task %d: got subtask %q, want %q", idx, g.Children[0].Task, want.Children[0].Task)
+		}
+	}
+}
+
+func TestICalImport(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"SUMMARY:not a todo\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"UID:abc\r\n" +
+		"SUMMARY:rotate\r\n" +
+		"  credentials\r\n" +
+		"STATUS:COMPLETED\r\n" +
+		"DUE;VALUE=DATE:20261101\r\n" +
+		"PRIORITY:9\r\n" +
+		"END:VTODO\r\n" +
+		"END:VCALENDAR\r\n"
+
+	imported, err := todo.Import(strings.NewReader(ics), todo.FormatICal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imported) != 1 {
+		t.Fatalf("got %d todos, want 1", len(imported))
+	}
+
+	i := imported[0]
+	if i.Task != "rotate credentials" || !i.Done || i.Priority != todo.PriorityLow {
+		t.Errorf("got %+v", i)
+	}
+	if want := time.Date(2026, 11, 1, 0, 0, 0, 0, time.Local); !i.DueAt.Equal(want) {
+		t.Errorf("got due %v, want %v", i.DueAt, want)
+	}
+}
+
+func TestICalImportTree(t *testing.T) {
+	vtodo := func(uid, summary, related string) string {
+		s := "BEGIN:VTODO\r\nUID:" + uid + "\r\nSUMMARY:" + summary + "\r\n"
+		if related != "" {
+			s += "RELATED-TO:" + related + "\r\n"
+		}
+		return s + "END:VTODO\r\n"
+	}
+	ics := "BEGIN:VCALENDAR\r\n" +
+		vtodo("p", "parent", "") +
+		vtodo("g", "grandchild", "c") +
+		vtodo("c", "child", "p") +
+		vtodo("s", "self", "s") +
+		vtodo("o", "orphan", "missing") +
+		"END:VCALENDAR\r\n"
+
+	imported, err := todo.Import(strings.NewReader(ics), todo.FormatICal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := titles(imported), "parent (child (grandchild)), self, orphan"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
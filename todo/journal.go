@@ -0,0 +1,235 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/alexeyco/simpletable"
+)
+
+// maxJournal is how many operations a journal keeps before dropping the oldest.
+const maxJournal = 100
+
+// Operation is one mutation of the list, with the whole list as it was
+// before and after so it can be undone and redone.
+type Operation struct {
+	Description string
+	Time        time.Time
+	Before      json.RawMessage
+	After       json.RawMessage
+	// Archive is set for an operation that moved todos to the archive,
+	// which undoing and redoing it have to update as well.
+	Archive bool `json:",omitempty"`
+}
+
+// Journal records operations in a file next to the data file. Undone counts
+// the operations at the end of the journal that have been undone and can
+// still be redone.
+type Journal struct {
+	Path       string      `json:"-"`
+	Operations []Operation `json:",omitempty"`
+	Undone     int         `json:",omitempty"`
+	// Sealed holds the rest encrypted while Passphrase is set.
+	Sealed *Sealed `json:",omitempty"`
+}
+
+// JournalPath is where the journal for the data file at path is kept.
+func JournalPath(path string) string {
+	return path + ".journal"
+}
+
+func LoadJournal(path string) (*Journal, error) {
+	j := &Journal{Path: path}
+
+	file, err := ioutil.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return j, nil
+		}
+		return nil, err
+	}
+
+	if len(file) == 0 {
+		return j, nil
+	}
+	if err := json.Unmarshal(file, j); err != nil {
+		return nil, err
+	}
+	if j.Sealed != nil {
+		plain, err := j.Sealed.open()
+		if err != nil {
+			return nil, err
+		}
+		j.Sealed = nil
+		if err := json.Unmarshal(plain, j); err != nil {
+			return nil, err
+		}
+	}
+
+	return j, nil
+}
+
+func (j *Journal) Store() error {
+	data, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+
+	if Passphrase != "" {
+		sealed, err := seal(data)
+		if err != nil {
+			return err
+		}
+		if data, err = json.Marshal(Journal{Sealed: sealed}); err != nil {
+			return err
+		}
+	}
+
+	return writeFileAtomic(j.Path, data, 0644)
+}
+
+// Record adds an operation that turned before into after. Anything that was
+// undone and not redone is forgotten.
+func (j *Journal) Record(description string, before, after Todos) error {
+	b, err := json.Marshal(before)
+	if err != nil {
+		return err
+	}
+	a, err := json.Marshal(after)
+	if err != nil {
+		return err
+	}
+
+	j.Operations = append(j.Operations[:len(j.Operations)-j.Undone], Operation{
+		Description: description,
+		Time:        time.Now(),
+		Before:      b,
+		After:       a,
+	})
+	j.Undone = 0
+
+	if len(j.Operations) > maxJournal {
+		j.Operations = j.Operations[len(j.Operations)-maxJournal:]
+	}
+
+	return nil
+}
+
+// RecordArchive is Record for moving todos from before to the archive.
+func (j *Journal) RecordArchive(description string, before, after Todos) error {
+	if err := j.Record(description, before, after); err != nil {
+		return err
+	}
+	j.Operations[len(j.Operations)-1].Archive = true
+
+	return nil
+}
+
+// Undo reverts t to the state before the last operation that is not undone yet.
+func (j *Journal) Undo(t *Todos) (Operation, error) {
+	pos := len(j.Operations) - j.Undone
+	if pos == 0 {
+		return Operation{}, errors.New("nothing to undo")
+	}
+
+	op := j.Operations[pos-1]
+	if err := restore(t, op.After, op.Before); err != nil {
+		return Operation{}, err
+	}
+	j.Undone++
+
+	return op, nil
+}
+
+// Redo applies again the most recently undone operation.
+func (j *Journal) Redo(t *Todos) (Operation, error) {
+	if j.Undone == 0 {
+		return Operation{}, errors.New("nothing to redo")
+	}
+
+	op := j.Operations[len(j.Operations)-j.Undone]
+	if err := restore(t, op.Before, op.After); err != nil {
+		return Operation{}, err
+	}
+	j.Undone--
+
+	return op, nil
+}
+
+// restore replaces t with to, but only if t still looks like from; otherwise
+// the list was changed outside the journal and replacing it would lose work.
+func restore(t *Todos, from, to json.RawMessage) error {
+	current, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(current, from) {
+		return errors.New("the list was changed outside of the journal, refusing to overwrite it")
+	}
+
+	ls := Todos{}
+	if err := json.Unmarshal(to, &ls); err != nil {
+		return err
+	}
+	*t = ls
+
+	return nil
+}
+
+// Clone returns a deep copy of the list, suitable as the "before" of Record.
+func (t *Todos) Clone() Todos {
+	data, _ := json.Marshal(t)
+
+	ls := Todos{}
+	json.Unmarshal(data, &ls)
+
+	return ls
+}
+
+// PrintHistory prints the last n operations, newest first.
+func (j *Journal) PrintHistory(n int) {
+
+	table := simpletable.New()
+
+	table.Header = &simpletable.Header{
+		Cells: []*simpletable.Cell{
+			{Align: simpletable.AlignCenter, Text: "#"},
+			{Align: simpletable.AlignCenter, Text: "Operation"},
+			{Align: simpletable.AlignRight, Text: "Time"},
+			{Align: simpletable.AlignCenter, Text: "State"},
+		},
+	}
+
+	var cells [][]*simpletable.Cell
+
+	for idx := len(j.Operations) - 1; idx >= 0 && len(cells) < n; idx-- {
+		op := j.Operations[idx]
+		description := blue(op.Description)
+		state := green("applied")
+		if idx >= len(j.Operations)-j.Undone {
+			description = gray(op.Description)
+			state = gray("undone")
+		}
+		cells = append(cells, []*simpletable.Cell{
+			{Text: fmt.Sprintf("%d", idx+1)},
+			{Text: description},
+			{Text: op.Time.Format(time.RFC822)},
+			{Text: state},
+		})
+	}
+
+	table.Body = &simpletable.Body{Cells: cells}
+
+	table.Footer = &simpletable.Footer{Cells: []*simpletable.Cell{
+		{Align: simpletable.AlignCenter, Span: 4, Text: fmt.Sprintf("%d operations can be undone, %d redone", len(j.Operations)-j.Undone, j.Undone)},
+	}}
+
+	table.SetStyle(simpletable.StyleUnicode)
+
+	table.Println()
+}
@@ -0,0 +1,123 @@
+package todo_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/example/todo"
+)
+
+// record adds task to todos and records it in journal.
+func record(t *testing.T, journal *todo.Journal, todos *todo.Todos, task string) {
+	t.Helper()
+
+	before := todos.Clone()
+	todos.Add(task)
+	if err := journal.Record("add "+task, before, *todos); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJournalUndoRedo(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todos.json.journal")
+	journal := &todo.Journal{Path: path}
+	todos := &todo.Todos{}
+	for _, task := range []string{"a", "b", "c"} {
+		record(t, journal, todos, task)
+	}
+
+	for _, want := range []string{"add c", "add b"} {
+		op, err := journal.Undo(todos)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if op.Description != want {
+			t.Errorf("undid %q, want %q", op.Description, want)
+		}
+	}
+	if got := tasks(*todos); !equal(got, []string{"a"}) {
+		t.Errorf("after two undos got %v", got)
+	}
+
+	// The journal survives a reload with its undone operations.
+	if err := journal.Store(); err != nil {
+		t.Fatal(err)
+	}
+	journal, err := todo.LoadJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if op, err := journal.Redo(todos); err != nil || op.Description != "add b" {
+		t.Fatalf("redid %q, %v, want add b", op.Description, err)
+	}
+	if got := tasks(*todos); !equal(got, []string{"a", "b"}) {
+		t.Errorf("after a redo got %v", got)
+	}
+
+	// A new operation forgets the undone one.
+	record(t, journal, todos, "d")
+	if _, err := journal.Redo(todos); err == nil {
+		t.Error("redid an operation dropped by a new one")
+	}
+	if n := len(journal.Operations); n != 3 {
+		t.Errorf("got %d operations, want 3", n)
+	}
+
+	for range journal.Operations {
+		if _, err := journal.Undo(todos); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(*todos) != 0 {
+		t.Errorf("undoing everything left %v", tasks(*todos))
+	}
+	if _, err := journal.Undo(todos); err == nil {
+		t.Error("undid more than was recorded")
+	}
+}
+
+func TestJournalLimit(t *testing.T) {
+	journal := &todo.Journal{}
+	todos := &todo.Todos{}
+	for n := 1; n <= 105; n++ {
+		record(t, journal, todos, fmt.Sprint(n))
+	}
+
+	// Only the last 100 operations are kept.
+	if n := len(journal.Operations); n != 100 {
+		t.Fatalf("got %d operations, want 100", n)
+	}
+	if got := journal.Operations[0].Description; got != "add 6" {
+		t.Errorf("oldest operation is %q, want add 6", got)
+	}
+
+	for range journal.Operations {
+		if _, err := journal.Undo(todos); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := tasks(*todos); !equal(got, []string{"1", "2", "3", "4", "5"}) {
+		t.Errorf("undoing everything left %v", got)
+	}
+}
+
+func TestJournalChangedOutside(t *testing.T) {
+	journal := &todo.Journal{}
+	todos := &todo.Todos{}
+	record(t, journal, todos, "a")
+	todos.Add("added without the journal")
+
+	_, err := journal.Undo(todos)
+	if err == nil || !strings.Contains(err.Error(), "changed outside of the journal") {
+		t.Errorf("got %v, want a refusal", err)
+	}
+	if got := tasks(*todos); !equal(got, []string{"a", "added without the journal"}) {
+		t.Errorf("refused undo changed the list to %v", got)
+	}
+	if _, err := journal.Redo(todos); err == nil {
+		t.Error("redid after a refused undo")
+	}
+}
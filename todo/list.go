@@ -0,0 +1,130 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// List is a todo list as it is stored: the todos and the ID the next new
+// todo gets. NextID only grows, so the IDs of deleted and archived todos are
+// never handed out again, while a plain Todos numbers new todos after the
+// highest ID it holds.
+type List struct {
+	Todos
+	NextID int
+}
+
+// next is the ID allocate hands out next.
+func (l *List) next() int {
+	if next := l.Todos.nextID(); next > l.NextID {
+		return next
+	}
+
+	return l.NextID
+}
+
+func (l *List) allocate() int {
+	id := l.next()
+	l.NextID = id + 1
+
+	return id
+}
+
+func (l *List) Add(task string) int {
+	return add(&l.Todos, task, l.allocate)
+}
+
+// AddSub adds a subtask under the todo with the given parent ID.
+func (l *List) AddSub(parent int, task string) (int, error) {
+	return l.Todos.addSub(parent, task, l.allocate)
+}
+
+// Complete is Todos.Complete numbering the next occurrence of a recurring
+// todo from NextID.
+func (l *List) Complete(id int) error {
+	return l.Todos.completeID(id, false, l.allocate)
+}
+
+func (l *List) ForceComplete(id int) error {
+	return l.Todos.completeID(id, true, l.allocate)
+}
+
+func (l *List) CompleteAll(ids []int, force bool) error {
+	next := l.NextID
+	if err := l.Todos.completeAll(ids, force, l.allocate); err != nil {
+		l.NextID = next
+		return err
+	}
+
+	return nil
+}
+
+// Merge is Todos.Merge numbering the added todos from NextID.
+func (l *List) Merge(imported Todos) int {
+	return mergeInto(&l.Todos, imported, l.allocate)
+}
+
+// Load reads the list stored in filename. A missing or empty file leaves l
+// as it is.
+func (l *List) Load(filename string) error {
+	file, err := ioutil.ReadFile(filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	if len(file) == 0 {
+		return nil
+	}
+
+	list, err := DecodeList(file)
+	if err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+
+	// Before NextID was kept, IDs were taken after the highest one in the
+	// list, so archived todos may hold higher ones.
+	if version, _ := formatVersion(file); version < nextIDVersion {
+		if err := list.reserveArchived(ArchivePath(filename)); err != nil {
+			return err
+		}
+	}
+	*l = list
+
+	return nil
+}
+
+// Store writes the list to filename, backing up a file in an older format
+// before it is replaced.
+func (l *List) Store(filename string) error {
+	if file, err := ioutil.ReadFile(filename); err == nil && len(file) > 0 {
+		if err := backup(filename, file); err != nil {
+			return err
+		}
+	}
+
+	data, err := l.Encode()
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(filename, data, 0644)
+}
+
+// reserveArchived moves NextID past the IDs of the todos in the archive at
+// path.
+func (l *List) reserveArchived(path string) error {
+	archived := Todos{}
+	if err := archived.Load(path); err != nil {
+		return err
+	}
+	if next := archived.nextID(); next > l.NextID {
+		l.NextID = next
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package todo_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/example/todo"
+)
+
+func TestListDeleteThenAdd(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, store := range []todo.Storage{
+		&todo.JSONFile{Path: filepath.Join(dir, "todos.json")},
+		&todo.SQLite{Path: filepath.Join(dir, "todos.db")},
+	} {
+		l := &todo.List{}
+		l.Add("one")
+		two := l.Add("two")
+		if err := l.Delete(two); err != nil {
+			t.Fatal(err)
+		}
+		if id := l.Add("three"); id != 3 {
+			t.Errorf("%T: three got ID %d, want 3", store, id)
+		}
+		if err := l.Delete(3); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Store(l); err != nil {
+			t.Fatal(err)
+		}
+
+		loaded := &todo.List{}
+		if err := store.Load(loaded); err != nil {
+			t.Fatal(err)
+		}
+		if id := loaded.Add("four"); id != 4 {
+			t.Errorf("%T: four got ID %d after a reload, want 4", store, id)
+		}
+	}
+}
+
+func TestListReservesArchivedIDs(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "todos.json")
+
+	// A file from before NextID was kept, whose todo 2 was archived.
+	old := `{"Version":4,"Todos":[{"ID":1,"Task":"one"}]}`
+	if err := os.WriteFile(path, []byte(old), 0644); err != nil {
+		t.Fatal(err)
+	}
+	archived := todo.Todos{}
+	archived.Add("one")
+	archived.Add("two")
+	if err := archived.Delete(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := archived.Store(todo.ArchivePath(path)); err != nil {
+		t.Fatal(err)
+	}
+
+	l := &todo.List{}
+	if err := l.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if id := l.Add("three"); id != 3 {
+		t.Errorf("got ID %d, want 3", id)
+	}
+}
+
+func TestTodosStoreKeepsNextID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todos.json")
+
+	l := &todo.List{}
+	l.Add("one")
+	l.Add("two")
+	if err := l.Delete(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Store(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// Round-trip the file through a plain Todos, which has no NextID.
+	var todos todo.Todos
+	if err := todos.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := todos.Store(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := &todo.List{}
+	if err := loaded.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if id := loaded.Add("three"); id != 3 {
+		t.Errorf("got ID %d, want 3", id)
+	}
+}
@@ -0,0 +1,23 @@
+package todo
+
+import "os"
+
+// Lock takes an exclusive advisory lock for the data file at path, waiting
+// until any other holder releases it. The lock lives in a separate
+// path+".lock" file so it survives Store replacing the data file.
+func Lock(path string) (unlock func() error, err error) {
+	f, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return func() error {
+		defer f.Close()
+		return unlockFile(f)
+	}, nil
+}
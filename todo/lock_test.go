@@ -0,0 +1,63 @@
+package todo_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/example/todo"
+)
+
+func TestConcurrentAdd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".todos.json")
+	const workers = 50
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for n := 0; n < workers; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+
+			unlock, err := todo.Lock(path)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer unlock()
+
+			todos := &todo.Todos{}
+			if err := todos.Load(path); err != nil {
+				errs <- err
+				return
+			}
+			todos.Add(fmt.Sprintf("task %d", n))
+			if err := todos.Store(path); err != nil {
+				errs <- err
+			}
+		}(n)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	todos := &todo.Todos{}
+	if err := todos.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if len(*todos) != workers {
+		t.Fatalf("got %d todos, want %d", len(*todos), workers)
+	}
+
+	seen := map[int]bool{}
+	for _, item := range *todos {
+		if seen[item.ID] {
+			t.Fatalf("duplicate id %d", item.ID)
+		}
+		seen[item.ID] = true
+	}
+}
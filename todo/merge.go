@@ -0,0 +1,373 @@
+package todo
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Conflict is a todo changed in different ways on the two sides of a merge.
+// The merge keeps our side of it.
+type Conflict struct {
+	ID     int
+	Field  string
+	Ours   string
+	Theirs string
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("todo %d: %s is %q in ours but %q in theirs, kept ours", c.ID, c.Field, c.Ours, c.Theirs)
+}
+
+// node is a todo taken out of the tree, with the ID of its parent (0 at
+// the top level) in place of its subtasks.
+type node struct {
+	item   item
+	parent int
+}
+
+// flatten returns the todos of the tree by ID, and their IDs in tree order.
+func flatten(t Todos) (map[int]node, []int) {
+	nodes := map[int]node{}
+	var order []int
+
+	var visit func(ls Todos, parent int)
+	visit = func(ls Todos, parent int) {
+		for _, i := range ls {
+			flat := i
+			flat.Children = nil
+			nodes[i.ID] = node{item: flat, parent: parent}
+			order = append(order, i.ID)
+			visit(i.Children, i.ID)
+		}
+	}
+	visit(t, 0)
+
+	return nodes, order
+}
+
+func (n node) equal(other node) bool {
+	a, _ := json.Marshal(n.item)
+	b, _ := json.Marshal(other.item)
+
+	return n.parent == other.parent && string(a) == string(b)
+}
+
+// mergeFields are the parts of a todo merged as a whole: a side that changed
+// one wins over a side that did not, and changes on both sides conflict
+// unless they agree. Tags and tracked time are merged as sets instead.
+var mergeFields = []struct {
+	name string
+	key  func(n node) string
+	set  func(dst *node, src node)
+}{
+	{"text", func(n node) string { return n.item.Task }, func(dst *node, src node) { dst.item.Task = src.item.Task }},
+	{"done", func(n node) string { return strconv.FormatBool(n.item.Done) }, func(dst *node, src node) {
+		dst.item.Done = src.item.Done
+		dst.item.CompletedAt = src.item.CompletedAt
+	}},
+	{"priority", func(n node) string { return n.item.Priority.String() }, func(dst *node, src node) { dst.item.Priority = src.item.Priority }},
+	{"due", func(n node) string { return formatValue(n.item.DueAt) }, func(dst *node, src node) { dst.item.DueAt = src.item.DueAt }},
+	{"repeat", formatNodeRecurrence, func(dst *node, src node) { dst.item.Recur = src.item.Recur }},
+	{"parent", func(n node) string { return strconv.Itoa(n.parent) }, func(dst *node, src node) { dst.parent = src.parent }},
+}
+
+func formatNodeRecurrence(n node) string {
+	return formatRecurrence(n.item)
+}
+
+// MergeThreeWay merges the changes made to base in ours and in theirs, such
+// as two branches of a task file kept in git. Todos are matched by ID.
+// Changes made on one side only are taken over; a todo deleted on one side
+// and changed on the other is kept, and other changes that clash are
+// reported as conflicts with our side kept. Todos added on both sides under
+// the same ID are both kept, theirs with a new ID.
+func MergeThreeWay(base, ours, theirs Todos) (Todos, []Conflict) {
+	return mergeThreeWay(base, ours, theirs, 1)
+}
+
+// MergeLists is MergeThreeWay for whole lists. Todos renamed on theirs side
+// take IDs no side has handed out yet, and the merged NextID is the highest
+// of the three.
+func MergeLists(base, ours, theirs List) (List, []Conflict) {
+	next := base.next()
+	for _, l := range []List{ours, theirs} {
+		if n := l.next(); n > next {
+			next = n
+		}
+	}
+
+	merged, conflicts := mergeThreeWay(base.Todos, ours.Todos, theirs.Todos, next)
+	l := List{Todos: merged, NextID: next}
+	l.NextID = l.next()
+
+	return l, conflicts
+}
+
+// mergeThreeWay is MergeThreeWay giving renamed todos IDs from next on.
+func mergeThreeWay(base, ours, theirs Todos, next int) (Todos, []Conflict) {
+	baseNodes, baseOrder := flatten(base)
+	ourNodes, ourOrder := flatten(ours)
+	theirNodes, theirOrder := flatten(theirs)
+
+	// Give todos that theirs added under an ID ours used for another new
+	// todo a fresh ID.
+	for _, nodes := range []map[int]node{baseNodes, ourNodes, theirNodes} {
+		for id := range nodes {
+			if id >= next {
+				next = id + 1
+			}
+		}
+	}
+	renamed := map[int]int{}
+	for _, id := range theirOrder {
+		_, inBase := baseNodes[id]
+		if o, ok := ourNodes[id]; ok && !inBase && !o.equal(theirNodes[id]) {
+			renamed[id] = next
+			next++
+		}
+	}
+	if len(renamed) > 0 {
+		nodes := map[int]node{}
+		for idx, id := range theirOrder {
+			n := theirNodes[id]
+			if newID, ok := renamed[n.parent]; ok {
+				n.parent = newID
+			}
+			if newID, ok := renamed[id]; ok {
+				n.item.ID = newID
+				id = newID
+			}
+			nodes[id] = n
+			theirOrder[idx] = id
+		}
+		theirNodes = nodes
+	}
+
+	var conflicts []Conflict
+	merged := map[int]node{}
+
+	ids := map[int]bool{}
+	for _, order := range [][]int{baseOrder, ourOrder, theirOrder} {
+		for _, id := range order {
+			ids[id] = true
+		}
+	}
+	for id := range ids {
+		b, inBase := baseNodes[id]
+		o, inOurs := ourNodes[id]
+		t, inTheirs := theirNodes[id]
+
+		switch {
+		case inOurs && inTheirs && inBase:
+			n, c := mergeNode(b, o, t)
+			merged[id] = n
+			conflicts = append(conflicts, c...)
+		case inOurs && inTheirs:
+			merged[id] = o
+		case inOurs && inBase:
+			// Deleted in theirs; keep it if ours changed it since.
+			if !o.equal(b) {
+				merged[id] = o
+				conflicts = append(conflicts, Conflict{ID: id, Field: "todo", Ours: "changed", Theirs: "deleted"})
+			}
+		case inTheirs && inBase:
+			if !t.equal(b) {
+				merged[id] = t
+				conflicts = append(conflicts, Conflict{ID: id, Field: "todo", Ours: "deleted", Theirs: "changed"})
+			}
+		case inOurs:
+			merged[id] = o
+		case inTheirs:
+			merged[id] = t
+		}
+	}
+
+	sort.Slice(conflicts, func(a, b int) bool {
+		return conflicts[a].ID < conflicts[b].ID
+	})
+
+	// Keep our order of the todos, unless only theirs reordered them.
+	primary, secondary := ourOrder, theirOrder
+	if sameOrder(baseOrder, ourOrder, theirNodes) && !sameOrder(baseOrder, theirOrder, ourNodes) {
+		primary, secondary = theirOrder, ourOrder
+	}
+
+	return buildTree(merged, mergeOrder(primary, secondary)), conflicts
+}
+
+func mergeNode(b, o, t node) (node, []Conflict) {
+	var conflicts []Conflict
+	n := o
+
+	for _, f := range mergeFields {
+		base, ours, theirs := f.key(b), f.key(o), f.key(t)
+		switch {
+		case ours == theirs, theirs == base:
+		case ours == base:
+			f.set(&n, t)
+		default:
+			conflicts = append(conflicts, Conflict{ID: o.item.ID, Field: f.name, Ours: ours, Theirs: theirs})
+		}
+	}
+
+	n.item.Projects = mergeTags(b.item.Projects, o.item.Projects, t.item.Projects)
+	n.item.Tags = mergeTags(b.item.Tags, o.item.Tags, t.item.Tags)
+	n.item.Intervals = mergeIntervals(o.item.Intervals, t.item.Intervals)
+
+	return n, conflicts
+}
+
+// mergeTags keeps our tags, adds those theirs added and drops those theirs removed.
+func mergeTags(base, ours, theirs []string) []string {
+	var merged []string
+	for _, tag := range ours {
+		if !hasTag(base, tag) || hasTag(theirs, tag) {
+			merged = appendTag(merged, tag)
+		}
+	}
+	for _, tag := range theirs {
+		if !hasTag(base, tag) {
+			merged = appendTag(merged, tag)
+		}
+	}
+
+	return merged
+}
+
+// mergeIntervals joins the work tracked on both sides. Intervals started at
+// the same time are the same one, and ours is kept.
+func mergeIntervals(ours, theirs []Interval) []Interval {
+	merged := append([]Interval(nil), ours...)
+	for _, iv := range theirs {
+		found := false
+		for _, have := range ours {
+			if have.Start.Equal(iv.Start) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, iv)
+		}
+	}
+
+	sort.SliceStable(merged, func(a, b int) bool {
+		return merged[a].Start.Before(merged[b].Start)
+	})
+
+	return merged
+}
+
+// sameOrder reports whether side keeps the todos it shares with base and
+// other in the order base has them.
+func sameOrder(base, side []int, other map[int]node) bool {
+	inBase, inSide := map[int]bool{}, map[int]bool{}
+	for _, id := range base {
+		inBase[id] = true
+	}
+	for _, id := range side {
+		inSide[id] = true
+	}
+
+	shared := func(order []int, in map[int]bool) []int {
+		var ids []int
+		for _, id := range order {
+			if _, ok := other[id]; ok && in[id] {
+				ids = append(ids, id)
+			}
+		}
+		return ids
+	}
+	a, b := shared(base, inSide), shared(side, inBase)
+
+	if len(a) != len(b) {
+		return false
+	}
+	for idx := range a {
+		if a[idx] != b[idx] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// mergeOrder takes the primary order and puts the todos only the secondary
+// has right after the todo they follow there.
+func mergeOrder(primary, secondary []int) []int {
+	order := append([]int(nil), primary...)
+	pos := map[int]int{}
+	for idx, id := range order {
+		pos[id] = idx
+	}
+
+	prev := -1
+	for _, id := range secondary {
+		if idx, ok := pos[id]; ok {
+			prev = idx
+			continue
+		}
+
+		at := prev + 1
+		order = append(order[:at], append([]int{id}, order[at:]...)...)
+		for idx := at; idx < len(order); idx++ {
+			pos[order[idx]] = idx
+		}
+		prev = at
+	}
+
+	return order
+}
+
+// buildTree puts the merged todos back into a tree. A todo whose parent is
+// gone ends up at the top level.
+func buildTree(nodes map[int]node, order []int) Todos {
+	children := map[int][]int{}
+	for _, id := range order {
+		n, ok := nodes[id]
+		if !ok {
+			continue
+		}
+		parent := n.parent
+		if _, ok := nodes[parent]; !ok || parent == id {
+			parent = 0
+		}
+		children[parent] = append(children[parent], id)
+	}
+
+	placed := map[int]bool{}
+	var build func(parent int) Todos
+	build = func(parent int) Todos {
+		var ls Todos
+		for _, id := range children[parent] {
+			if placed[id] {
+				continue
+			}
+			placed[id] = true
+			i := nodes[id].item
+			i.Children = build(id)
+			ls = append(ls, i)
+		}
+		return ls
+	}
+
+	t := build(0)
+	if t == nil {
+		t = Todos{}
+	}
+
+	// Parents changed on both sides may have made a cycle; its todos are
+	// put at the top level rather than lost.
+	for _, id := range order {
+		if _, ok := nodes[id]; ok && !placed[id] {
+			placed[id] = true
+			i := nodes[id].item
+			i.Children = build(id)
+			t = append(t, i)
+		}
+	}
+
+	return t
+}
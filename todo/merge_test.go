@@ -0,0 +1,243 @@
+package todo_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/example/todo"
+)
+
+// decode reads a list of todos written as the JSON array of a task file.
+func decode(t *testing.T, todos string) todo.Todos {
+	t.Helper()
+
+	ls, err := todo.Decode([]byte(fmt.Sprintf(`{"Version":%d,"Todos":%s}`, todo.FormatVersion, todos)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ls
+}
+
+// render writes a tree of todos as "1 a, 2 b (3 c)".
+func render(todos todo.Todos) string {
+	var parts []string
+	for _, i := range todos {
+		part := fmt.Sprintf("%d %s", i.ID, i.Task)
+		if len(i.Children) > 0 {
+			part += " (" + render(i.Children) + ")"
+		}
+		parts = append(parts, part)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func TestMergeThreeWay(t *testing.T) {
+	const base = `[{"ID":1,"Task":"a"},{"ID":2,"Task":"b"}]`
+
+	tests := []struct {
+		name      string
+		base      string
+		ours      string
+		theirs    string
+		want      string
+		conflicts []string
+	}{
+		{
+			name:   "one-sided edits",
+			base:   base,
+			ours:   `[{"ID":1,"Task":"a!"},{"ID":2,"Task":"b"}]`,
+			theirs: `[{"ID":1,"Task":"a"},{"ID":2,"Task":"b!"}]`,
+			want:   "1 a!, 2 b!",
+		},
+		{
+			name:   "same edit on both sides",
+			base:   base,
+			ours:   `[{"ID":1,"Task":"a!"},{"ID":2,"Task":"b"}]`,
+			theirs: `[{"ID":1,"Task":"a!"},{"ID":2,"Task":"b"}]`,
+			want:   "1 a!, 2 b",
+		},
+		{
+			name:      "conflicting edits keep ours",
+			base:      base,
+			ours:      `[{"ID":1,"Task":"ours"},{"ID":2,"Task":"b","Priority":"high"}]`,
+			theirs:    `[{"ID":1,"Task":"theirs"},{"ID":2,"Task":"b","Priority":"low"}]`,
+			want:      "1 ours, 2 b",
+			conflicts: []string{"1 text", "2 priority"},
+		},
+		{
+			name:   "delete on one side",
+			base:   base,
+			ours:   `[{"ID":1,"Task":"a"}]`,
+			theirs: base,
+			want:   "1 a",
+		},
+		{
+			name:      "deleted in ours, changed in theirs",
+			base:      base,
+			ours:      `[{"ID":1,"Task":"a"}]`,
+			theirs:    `[{"ID":1,"Task":"a"},{"ID":2,"Task":"b!"}]`,
+			want:      "1 a, 2 b!",
+			conflicts: []string{"2 todo"},
+		},
+		{
+			name:      "changed in ours, deleted in theirs",
+			base:      base,
+			ours:      `[{"ID":1,"Task":"a!"},{"ID":2,"Task":"b"}]`,
+			theirs:    `[{"ID":2,"Task":"b"}]`,
+			want:      "1 a!, 2 b",
+			conflicts: []string{"1 todo"},
+		},
+		{
+			name:   "same ID added on both sides",
+			base:   base,
+			ours:   `[{"ID":1,"Task":"a"},{"ID":2,"Task":"b"},{"ID":3,"Task":"ours"}]`,
+			theirs: `[{"ID":1,"Task":"a"},{"ID":2,"Task":"b"},{"ID":3,"Task":"theirs","Children":[{"ID":4,"Task":"sub"}]}]`,
+			want:   "1 a, 2 b, 5 theirs (4 sub), 3 ours",
+		},
+		{
+			name:   "same todo added on both sides",
+			base:   base,
+			ours:   `[{"ID":1,"Task":"a"},{"ID":2,"Task":"b"},{"ID":3,"Task":"c"}]`,
+			theirs: `[{"ID":1,"Task":"a"},{"ID":2,"Task":"b"},{"ID":3,"Task":"c"}]`,
+			want:   "1 a, 2 b, 3 c",
+		},
+		{
+			name:   "subtasks moved on both sides",
+			base:   base,
+			ours:   `[{"ID":1,"Task":"a"},{"ID":3,"Task":"c"},{"ID":2,"Task":"b"}]`,
+			theirs: `[{"ID":1,"Task":"a","Children":[{"ID":2,"Task":"b"}]}]`,
+			want:   "1 a (2 b), 3 c",
+		},
+		{
+			name:   "re-parenting into a cycle",
+			base:   base,
+			ours:   `[{"ID":1,"Task":"a","Children":[{"ID":2,"Task":"b"}]}]`,
+			theirs: `[{"ID":2,"Task":"b","Children":[{"ID":1,"Task":"a"}]}]`,
+			want:   "2 b (1 a)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, conflicts := todo.MergeThreeWay(decode(t, tt.base), decode(t, tt.ours), decode(t, tt.theirs))
+			if got := render(merged); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+
+			var got []string
+			for _, c := range conflicts {
+				got = append(got, fmt.Sprintf("%d %s", c.ID, c.Field))
+			}
+			if !equal(got, tt.conflicts) {
+				t.Errorf("got conflicts %v, want %v", got, tt.conflicts)
+			}
+		})
+	}
+}
+
+func TestMergeThreeWaySets(t *testing.T) {
+	base := decode(t, `[{"ID":1,"Task":"a","Tags":["x","y"],"Intervals":[
+		{"Start":"2026-01-01T09:00:00Z","Stop":"2026-01-01T10:00:00Z"}]}]`)
+	ours := decode(t, `[{"ID":1,"Task":"a","Tags":["x","y","ours"],"Intervals":[
+		{"Start":"2026-01-01T09:00:00Z","Stop":"2026-01-01T10:00:00Z"},
+		{"Start":"2026-01-03T09:00:00Z","Stop":"2026-01-03T10:00:00Z"}]}]`)
+	theirs := decode(t, `[{"ID":1,"Task":"a","Tags":["y","theirs"],"Intervals":[
+		{"Start":"2026-01-01T09:00:00Z","Stop":"2026-01-01T10:00:00Z"},
+		{"Start":"2026-01-02T09:00:00Z","Stop":"2026-01-02T10:00:00Z"}]}]`)
+
+	merged, conflicts := todo.MergeThreeWay(base, ours, theirs)
+	if len(conflicts) != 0 {
+		t.Errorf("got conflicts %v", conflicts)
+	}
+
+	if got, want := merged[0].Tags, []string{"y", "ours", "theirs"}; !equal(got, want) {
+		t.Errorf("got tags %v, want %v", got, want)
+	}
+
+	var starts []string
+	for _, iv := range merged[0].Intervals {
+		starts = append(starts, iv.Start.Format("2006-01-02"))
+	}
+	if want := []string{"2026-01-01", "2026-01-02", "2026-01-03"}; !equal(starts, want) {
+		t.Errorf("got intervals starting %v, want %v", starts, want)
+	}
+}
+
+// TestMergeLists goes through the steps of the git merge driver: the three
+// files are decoded, merged and the result stored over ours.
+func TestMergeLists(t *testing.T) {
+	todo.PrettyJSON = true
+	t.Cleanup(func() { todo.PrettyJSON = false })
+
+	dir := t.TempDir()
+	now := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+
+	base := todo.List{}
+	base.Add("a")
+	base.Add("b")
+	ours, theirs := todo.List{Todos: base.Clone()}, todo.List{Todos: base.Clone()}
+	ours.NextID, theirs.NextID = base.NextID, base.NextID
+
+	ours.Delete(2)
+	ours.Add("ours")
+	theirs.SetDue(1, now)
+	theirs.Add("theirs")
+
+	var files []string
+	for idx, l := range []todo.List{base, ours, theirs} {
+		path := filepath.Join(dir, fmt.Sprintf("%d.json", idx))
+		if err := l.Store(path); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, path)
+	}
+
+	var lists [3]todo.List
+	for idx, name := range files {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lists[idx], err = todo.DecodeList(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	merged, conflicts := todo.MergeLists(lists[0], lists[1], lists[2])
+	if len(conflicts) != 0 {
+		t.Errorf("got conflicts %v", conflicts)
+	}
+	if err := merged.Store(files[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(files[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte("\n  \"Todos\": [\n")) {
+		t.Errorf("stored file is not indented:\n%s", data)
+	}
+
+	result := todo.List{}
+	if err := result.Load(files[1]); err != nil {
+		t.Fatal(err)
+	}
+	// Both sides added todo 3; theirs is renamed and follows todo 1, as
+	// todo 2 it followed is gone.
+	if got, want := render(result.Todos), "1 a, 4 theirs, 3 ours"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if !result.Todos[0].DueAt.Equal(now) {
+		t.Errorf("due date of theirs was lost: %s", result.Todos[0].DueAt)
+	}
+	if id := result.Add("next"); id != 5 {
+		t.Errorf("next todo got ID %d, want 5", id)
+	}
+}
@@ -0,0 +1,238 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alexeyco/simpletable"
+)
+
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputCSV   = "csv"
+	OutputPlain = "plain"
+)
+
+// column is one field a listing can show. value returns an int, bool,
+// string, time.Time or time.Duration, which each output format writes its
+// own way.
+type column struct {
+	header string
+	align  int
+	value  func(i item, now time.Time) interface{}
+}
+
+var columns = map[string]column{
+	"id":        {"#", simpletable.AlignCenter, func(i item, _ time.Time) interface{} { return i.ID }},
+	"task":      {"Task", simpletable.AlignCenter, func(i item, _ time.Time) interface{} { return i.Task }},
+	"done":      {"Done?", simpletable.AlignCenter, func(i item, _ time.Time) interface{} { return i.Done }},
+	"priority":  {"Priority", simpletable.AlignCenter, func(i item, _ time.Time) interface{} { return i.Priority.String() }},
+	"due":       {"Due", simpletable.AlignRight, func(i item, _ time.Time) interface{} { return i.DueAt }},
+	"tags":      {"Tags", simpletable.AlignLeft, func(i item, _ time.Time) interface{} { return formatTags(i) }},
+	"repeat":    {"Repeat", simpletable.AlignLeft, func(i item, _ time.Time) interface{} { return formatRecurrence(i) }},
+	"elapsed":   {"Elapsed", simpletable.AlignRight, func(i item, now time.Time) interface{} { return i.Elapsed(now) }},
+	"created":   {"CreatedAt", simpletable.AlignRight, func(i item, _ time.Time) interface{} { return i.CreatedAt }},
+	"completed": {"CompletedAt", simpletable.AlignRight, func(i item, _ time.Time) interface{} { return i.CompletedAt }},
+}
+
+// DefaultColumns are the columns shown when none are picked.
+var DefaultColumns = []string{"id", "task", "done", "priority", "due", "tags", "repeat", "elapsed", "created", "completed"}
+
+// ParseColumns reads a comma-separated list of column names. An empty list
+// gives DefaultColumns.
+func ParseColumns(s string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("invalid column %q (use %s)", name, strings.Join(DefaultColumns, ", "))
+		}
+		names = append(names, name)
+	}
+
+	if len(names) == 0 {
+		return DefaultColumns, nil
+	}
+
+	return names, nil
+}
+
+func formatRecurrence(i item) string {
+	if i.Recur == nil {
+		return ""
+	}
+
+	return i.Recur.String()
+}
+
+// formatValue writes a column value for people to read.
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case bool:
+		if v {
+			return "yes"
+		}
+		return "no"
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC822)
+	case time.Duration:
+		if v == 0 {
+			return ""
+		}
+		return formatDuration(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// rawValue gives a column value in a form programs can parse: times in
+// RFC 3339 (null when unset) and durations in seconds.
+func rawValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case time.Time:
+		if v.IsZero() {
+			return nil
+		}
+		return v.Format(time.RFC3339)
+	case time.Duration:
+		return int64(v.Seconds())
+	default:
+		return v
+	}
+}
+
+// Output writes the todos matching f to w in the given format, showing the
+// named columns. Subtasks follow their parent; the table and plain formats
+// indent them.
+func (t *Todos) Output(w io.Writer, f Filter, format string, names []string) error {
+	switch format {
+	case OutputTable, "":
+		_, err := fmt.Fprintln(w, t.table(f, names, nil).String())
+		return err
+	case OutputJSON:
+		return t.outputJSON(w, f, names)
+	case OutputCSV:
+		return t.outputCSV(w, f, names)
+	case OutputPlain:
+		return t.outputPlain(w, f, names)
+	default:
+		return fmt.Errorf("unknown output format %q (use %s, %s, %s or %s)", format, OutputTable, OutputJSON, OutputCSV, OutputPlain)
+	}
+}
+
+// jsonRow is a todo written as a JSON object with its fields in the order
+// of the columns.
+type jsonRow struct {
+	names  []string
+	values []interface{}
+}
+
+func (r jsonRow) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for idx, name := range r.names {
+		if idx > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(r.values[idx])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+func (t *Todos) outputJSON(w io.Writer, f Filter, names []string) error {
+	now := time.Now()
+	rows := []jsonRow{}
+	t.walk(func(_ int, i *item) {
+		if !f.Match(*i) {
+			return
+		}
+		row := jsonRow{names: names}
+		for _, name := range names {
+			row.values = append(row.values, rawValue(columns[name].value(*i, now)))
+		}
+		rows = append(rows, row)
+	})
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(rows)
+}
+
+func (t *Todos) outputCSV(w io.Writer, f Filter, names []string) error {
+	cw := csv.NewWriter(w)
+	cw.Write(names)
+
+	now := time.Now()
+	t.walk(func(_ int, i *item) {
+		if !f.Match(*i) {
+			return
+		}
+		var record []string
+		for _, name := range names {
+			v := rawValue(columns[name].value(*i, now))
+			if v == nil {
+				v = ""
+			}
+			record = append(record, fmt.Sprint(v))
+		}
+		cw.Write(record)
+	})
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+func (t *Todos) outputPlain(w io.Writer, f Filter, names []string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	var headers []string
+	for _, name := range names {
+		headers = append(headers, columns[name].header)
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+
+	now := time.Now()
+	t.walk(func(depth int, i *item) {
+		if !f.Match(*i) {
+			return
+		}
+		var cells []string
+		for _, name := range names {
+			text := formatValue(columns[name].value(*i, now))
+			if name == "task" {
+				text = strings.Repeat("  ", depth) + text
+			}
+			cells = append(cells, text)
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	})
+
+	return tw.Flush()
+}
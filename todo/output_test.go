@@ -0,0 +1,122 @@
+package todo_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/example/todo"
+)
+
+func outputTodos(t *testing.T) todo.Todos {
+	return decode(t, `[
+		{"ID":1,"Task":"ship it","Priority":"high","DueAt":"2026-11-02T09:30:00Z",
+			"CreatedAt":"2026-10-01T08:00:00Z","Tags":["work"],
+			"Intervals":[{"Start":"2026-10-01T09:00:00Z","Stop":"2026-10-01T10:30:00Z"}],
+			"Children":[{"ID":2,"Task":"write, \"quote\"","Done":true,
+				"CreatedAt":"2026-10-01T08:00:00Z","CompletedAt":"2026-10-02T08:00:00Z"}]}]`)
+}
+
+func output(t *testing.T, todos todo.Todos, format, columns string) string {
+	t.Helper()
+
+	names, err := todo.ParseColumns(columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := todos.Output(&buf, todo.Filter{}, format, names); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.String()
+}
+
+func TestOutputFormats(t *testing.T) {
+	todos := outputTodos(t)
+
+	tests := []struct {
+		format  string
+		columns string
+		want    string
+	}{
+		{todo.OutputJSON, "task,id,done,due,elapsed", `[
+  {
+    "task": "ship it",
+    "id": 1,
+    "done": false,
+    "due": "2026-11-02T09:30:00Z",
+    "elapsed": 5400
+  },
+  {
+    "task": "write, \"quote\"",
+    "id": 2,
+    "done": true,
+    "due": null,
+    "elapsed": 0
+  }
+]
+`},
+		{todo.OutputCSV, "id,task,priority,tags,completed", `id,task,priority,tags,completed
+1,ship it,high,@work,
+2,"write, ""quote""",,,2026-10-02T08:00:00Z
+`},
+		{todo.OutputPlain, "id,task,done,elapsed", `#  Task              Done?  Elapsed
+1  ship it           no     1h30m
+2    write, "quote"  yes    
+`},
+	}
+
+	for _, tt := range tests {
+		if got := output(t, todos, tt.format, tt.columns); got != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.format, got, tt.want)
+		}
+	}
+
+	if err := todos.Output(&bytes.Buffer{}, todo.Filter{}, "xml", todo.DefaultColumns); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+	if _, err := todo.ParseColumns("id,size"); err == nil {
+		t.Error("expected an error for an unknown column")
+	}
+}
+
+func TestOutputColors(t *testing.T) {
+	todos := outputTodos(t)
+	t.Cleanup(func() { todo.Colors = true })
+
+	todo.Colors = true
+	if got := output(t, todos, todo.OutputTable, ""); !strings.Contains(got, todo.ColorBlue+"ship it") {
+		t.Errorf("table without colors:\n%s", got)
+	}
+
+	todo.Colors = false
+	for _, format := range []string{todo.OutputTable, todo.OutputJSON, todo.OutputCSV, todo.OutputPlain} {
+		if got := output(t, todos, format, ""); strings.Contains(got, "\x1b[") {
+			t.Errorf("%s: colored output with colors off:\n%s", format, got)
+		}
+	}
+}
+
+func TestUseColors(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	t.Setenv("NO_COLOR", "")
+	if todo.UseColors(f) {
+		t.Error("colors on for output to a file")
+	}
+
+	// NO_COLOR wins even on a terminal, where one is at hand.
+	t.Setenv("NO_COLOR", "1")
+	for _, out := range []*os.File{f, os.Stdout, os.Stderr} {
+		if todo.UseColors(out) {
+			t.Errorf("colors on for %s with NO_COLOR set", out.Name())
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package todo
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Priority int
+
+const (
+	PriorityNone Priority = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+)
+
+var priorityNames = map[Priority]string{
+	PriorityNone:   "",
+	PriorityLow:    "low",
+	PriorityMedium: "medium",
+	PriorityHigh:   "high",
+}
+
+func ParsePriority(s string) (Priority, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for p, name := range priorityNames {
+		if name == s {
+			return p, nil
+		}
+	}
+
+	return PriorityNone, fmt.Errorf("invalid priority %q (use low, medium or high)", s)
+}
+
+func (p Priority) String() string {
+	return priorityNames[p]
+}
+
+func (p Priority) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *Priority) UnmarshalText(text []byte) error {
+	parsed, err := ParsePriority(string(text))
+	if err != nil {
+		return err
+	}
+
+	*p = parsed
+	return nil
+}
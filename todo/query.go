@@ -0,0 +1,327 @@
+package todo
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Query is a parsed query expression such as
+//
+//	done:false priority>=medium created>2026-01-01 text~deploy
+//
+// It is a list of conditions that must all hold. Each condition compares a
+// field with a value using one of the operators
+//
+//	: or =   equal (for dates naming a whole day, on that day)
+//	!=       not equal
+//	< <= > >=  ordered comparison of numbers, priorities and dates
+//	~        contains, ignoring case
+//
+// on the fields id, text, done, priority, due, created, completed, project
+// and tag. A word without an operator is short for text~word, and values
+// with spaces can be quoted: text~"release notes". Dates are read by
+// ParseDate relative to Clock, as in due<"next friday", and dates compared
+// with "none" match todos that have no such date.
+type Query struct {
+	source     string
+	conditions []condition
+}
+
+type condition struct {
+	field string
+	op    string
+	value string
+
+	// date is the value of a date condition, read when it was parsed, and
+	// day whether it names a whole day.
+	date time.Time
+	day  bool
+}
+
+var queryFields = map[string]bool{
+	"id": true, "text": true, "done": true, "priority": true, "due": true,
+	"created": true, "completed": true, "project": true, "tag": true,
+}
+
+// queryOperators is ordered so that longer operators are tried first.
+var queryOperators = []string{"!=", ">=", "<=", ":", "=", "<", ">", "~"}
+
+// ParseQuery parses a query expression. Values are checked up front, so a
+// parsed query never fails to evaluate.
+func ParseQuery(s string) (Query, error) {
+	q := Query{source: strings.TrimSpace(s)}
+
+	words, err := splitQuery(s)
+	if err != nil {
+		return Query{}, err
+	}
+
+	for _, word := range words {
+		c, err := parseCondition(word)
+		if err != nil {
+			return Query{}, err
+		}
+		q.conditions = append(q.conditions, c)
+	}
+
+	return q, nil
+}
+
+// splitQuery splits s at spaces that are not inside double quotes, and
+// drops the quotes.
+func splitQuery(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	quoted, inWord := false, false
+
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			inWord = true
+		case unicode.IsSpace(r) && !quoted:
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote in query %q", s)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
+
+func parseCondition(word string) (condition, error) {
+	at, op := -1, ""
+	for _, candidate := range queryOperators {
+		if idx := strings.Index(word, candidate); idx > 0 && (at < 0 || idx < at) {
+			at, op = idx, candidate
+		}
+	}
+
+	field := ""
+	if at > 0 {
+		field = strings.ToLower(word[:at])
+	}
+	if !queryFields[field] {
+		// Not a condition, so a word to look for in the text.
+		return condition{field: "text", op: "~", value: word}, nil
+	}
+	c := condition{field: field, op: op, value: word[at+len(op):]}
+	if c.op == "=" {
+		c.op = ":"
+	}
+
+	var err error
+	switch c.field {
+	case "id":
+		_, err = strconv.Atoi(c.value)
+		err = checkOperator(c, err, ":", "!=", "<", "<=", ">", ">=")
+	case "done":
+		_, err = strconv.ParseBool(c.value)
+		err = checkOperator(c, err, ":", "!=")
+	case "priority":
+		if c.value != "none" {
+			_, err = ParsePriority(c.value)
+		}
+		err = checkOperator(c, err, ":", "!=", "<", "<=", ">", ">=")
+	case "due", "created", "completed":
+		if c.value != "none" {
+			c.date, c.day, err = parseDate(c.value, Clock())
+		}
+		err = checkOperator(c, err, ":", "!=", "<", "<=", ">", ">=")
+	default:
+		err = checkOperator(c, nil, ":", "!=", "~")
+	}
+	if err != nil {
+		return condition{}, fmt.Errorf("invalid query %q: %w", word, err)
+	}
+
+	return c, nil
+}
+
+func checkOperator(c condition, err error, allowed ...string) error {
+	if err != nil {
+		return err
+	}
+	for _, op := range allowed {
+		if c.op == op {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s cannot be compared with %s", c.field, c.op)
+}
+
+func (q Query) String() string {
+	return q.source
+}
+
+// Match reports whether the todo meets every condition of the query.
+func (q Query) Match(i item) bool {
+	for _, c := range q.conditions {
+		if !c.match(i) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c condition) match(i item) bool {
+	switch c.field {
+	case "id":
+		n, _ := strconv.Atoi(c.value)
+		return compare(c.op, i.ID-n)
+	case "done":
+		b, _ := strconv.ParseBool(c.value)
+		return (i.Done == b) == (c.op == ":")
+	case "priority":
+		p := PriorityNone
+		if c.value != "none" {
+			p, _ = ParsePriority(c.value)
+		}
+		return compare(c.op, int(i.Priority-p))
+	case "due":
+		return c.matchDate(i.DueAt)
+	case "created":
+		return c.matchDate(i.CreatedAt)
+	case "completed":
+		return c.matchDate(i.CompletedAt)
+	case "project":
+		return c.matchText(i.Projects)
+	case "tag":
+		return c.matchText(i.Tags)
+	default:
+		return c.matchText([]string{i.Task})
+	}
+}
+
+// compare applies an operator to the sign of a difference.
+func compare(op string, diff int) bool {
+	switch op {
+	case ":":
+		return diff == 0
+	case "!=":
+		return diff != 0
+	case "<":
+		return diff < 0
+	case "<=":
+		return diff <= 0
+	case ">":
+		return diff > 0
+	default:
+		return diff >= 0
+	}
+}
+
+func (c condition) matchDate(t time.Time) bool {
+	if c.value == "none" {
+		return t.IsZero() == (c.op == ":")
+	}
+	if t.IsZero() {
+		// A missing date is neither before nor after anything.
+		return c.op == "!="
+	}
+
+	d := c.date
+	if !c.day {
+		return compare(c.op, t.Compare(d))
+	}
+
+	// Against a whole day, times on that day count as equal to it.
+	switch {
+	case t.Before(d):
+		return compare(c.op, -1)
+	case t.Before(d.AddDate(0, 0, 1)):
+		return compare(c.op, 0)
+	default:
+		return compare(c.op, 1)
+	}
+}
+
+// matchText matches the condition against any of values; != and a
+// missing value mean none of them match.
+func (c condition) matchText(values []string) bool {
+	want := strings.TrimLeft(strings.ToLower(c.value), "+@")
+	for _, v := range values {
+		v = strings.ToLower(v)
+		if c.op == "~" && strings.Contains(v, strings.ToLower(c.value)) || c.op != "~" && v == want {
+			return c.op != "!="
+		}
+	}
+
+	return c.op == "!="
+}
+
+// Query returns the todos matching q, subtasks included, as a flat list.
+func (t *Todos) Query(q Query) Todos {
+	return t.Select(Filter{Query: q})
+}
+
+// Sort names a field to order todos by and the direction.
+type Sort struct {
+	Key  string
+	Desc bool
+}
+
+var sortKeys = map[string]func(a, b item) int{
+	"id":        func(a, b item) int { return a.ID - b.ID },
+	"created":   func(a, b item) int { return a.CreatedAt.Compare(b.CreatedAt) },
+	"completed": func(a, b item) int { return a.CompletedAt.Compare(b.CompletedAt) },
+	"due":       func(a, b item) int { return a.DueAt.Compare(b.DueAt) },
+	"priority":  func(a, b item) int { return int(a.Priority - b.Priority) },
+	"text":      func(a, b item) int { return strings.Compare(strings.ToLower(a.Task), strings.ToLower(b.Task)) },
+}
+
+// ParseSort reads a sort order written as "key", "key:asc" or "key:desc",
+// with key one of id, created, completed, due, priority and text.
+func ParseSort(s string) (Sort, error) {
+	key, dir, _ := strings.Cut(strings.ToLower(strings.TrimSpace(s)), ":")
+	if _, ok := sortKeys[key]; !ok {
+		return Sort{}, fmt.Errorf("invalid sort key %q (use id, created, completed, due, priority or text)", key)
+	}
+
+	switch dir {
+	case "", "asc":
+		return Sort{Key: key}, nil
+	case "desc":
+		return Sort{Key: key, Desc: true}, nil
+	default:
+		return Sort{}, fmt.Errorf("invalid sort direction %q (use asc or desc)", dir)
+	}
+}
+
+// Sort orders the todos by s. Subtasks stay under their parent and are
+// sorted among their siblings; todos that compare equal keep their order.
+func (t *Todos) Sort(s Sort) {
+	cmp, ok := sortKeys[s.Key]
+	if !ok {
+		return
+	}
+
+	ls := *t
+	sort.SliceStable(ls, func(a, b int) bool {
+		if s.Desc {
+			return cmp(ls[b], ls[a]) < 0
+		}
+		return cmp(ls[a], ls[b]) < 0
+	})
+
+	for idx := range ls {
+		ls[idx].Children.Sort(s)
+	}
+}
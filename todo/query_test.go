@@ -0,0 +1,156 @@
+package todo_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/example/todo"
+)
+
+func ids(todos todo.Todos) []int {
+	var got []int
+	for _, i := range todos {
+		got = append(got, i.ID)
+	}
+
+	return got
+}
+
+func equalIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for idx := range a {
+		if a[idx] != b[idx] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestParseQuery(t *testing.T) {
+	// A Wednesday afternoon.
+	todo.Clock = func() time.Time { return time.Date(2026, 10, 14, 15, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { todo.Clock = time.Now })
+
+	todos := decode(t, `[
+		{"ID":1,"Task":"deploy the release +release @work","Priority":"high","DueAt":"2026-10-14T09:00:00Z",
+			"Projects":["release"],"Tags":["work"],"CreatedAt":"2026-01-01T10:00:00Z",
+			"Children":[{"ID":4,"Task":"tag it","Priority":"low","DueAt":"2026-10-14T23:59:00Z"}]},
+		{"ID":2,"Task":"write release notes","Priority":"medium","DueAt":"2026-10-15T00:00:00Z",
+			"Tags":["home"],"Done":true,"CompletedAt":"2026-10-14T18:00:00Z"},
+		{"ID":3,"Task":"buy milk","Tags":["home","errand"]}]`)
+
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"", []int{1, 4, 2, 3}},
+		{"priority>=medium", []int{1, 2}},
+		{"priority>medium", []int{1}},
+		{"priority<=low", []int{4, 3}},
+		{"priority:none", []int{3}},
+		{"priority!=none", []int{1, 4, 2}},
+		{"PRIORITY=HIGH", []int{1}},
+		{`text~"release notes"`, []int{2}},
+		{`"release notes"`, []int{2}},
+		{"text~RELEASE", []int{1, 2}},
+		{"release", []int{1, 2}},
+		{"release milk", nil},
+		{"foo:bar", nil},
+		{"done:true", []int{2}},
+		{"done!=true priority:high", []int{1}},
+		{"id>=3", []int{4, 3}},
+		{"id:2", []int{2}},
+		{"due:none", []int{3}},
+		{"due!=none", []int{1, 4, 2}},
+		{"due:2026-10-14", []int{1, 4}},
+		{"due=today", []int{1, 4}},
+		{"due!=2026-10-14", []int{2, 3}},
+		{"due>2026-10-14", []int{2}},
+		{"due>=2026-10-14", []int{1, 4, 2}},
+		{"due<2026-10-15", []int{1, 4}},
+		{"due:2026-10-14T09:00:00Z", []int{1}},
+		{"due<now", []int{1}},
+		{"completed:today", []int{2}},
+		{"created<2026-02-01", []int{1}},
+		{"tag:home", []int{2, 3}},
+		{"tag:@home", []int{2, 3}},
+		{"tag!=home", []int{1, 4}},
+		{"tag~err", []int{3}},
+		{"project:release", []int{1}},
+		{"project!=release", []int{4, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		q, err := todo.ParseQuery(tt.query)
+		if err != nil {
+			t.Errorf("%s: %v", tt.query, err)
+			continue
+		}
+		if got := ids(todos.Query(q)); !equalIDs(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseQueryInvalid(t *testing.T) {
+	for _, query := range []string{
+		`text~"unterminated`,
+		"id:x",
+		"id~1",
+		"done:maybe",
+		"done<true",
+		"priority:urgent",
+		"priority~high",
+		"due:someday",
+		"due~2026",
+		"tag>home",
+	} {
+		if _, err := todo.ParseQuery(query); err == nil {
+			t.Errorf("%s: expected an error", query)
+		}
+	}
+}
+
+func TestSort(t *testing.T) {
+	todos := decode(t, `[
+		{"ID":1,"Task":"c","Priority":"low"},
+		{"ID":2,"Task":"a","Priority":"high","Children":[
+			{"ID":5,"Task":"z"},
+			{"ID":6,"Task":"y","Priority":"high"},
+			{"ID":7,"Task":"x"}]},
+		{"ID":3,"Task":"B","Priority":"high"},
+		{"ID":4,"Task":"d"}]`)
+
+	tests := []struct {
+		sort string
+		want string
+	}{
+		{"priority:desc", "2 a (6 y, 5 z, 7 x), 3 B, 1 c, 4 d"},
+		{"priority", "4 d, 1 c, 2 a (5 z, 7 x, 6 y), 3 B"},
+		{"text", "2 a (7 x, 6 y, 5 z), 3 B, 1 c, 4 d"},
+		{"TEXT:DESC", "4 d, 1 c, 3 B, 2 a (5 z, 6 y, 7 x)"},
+		{"id:desc", "4 d, 3 B, 2 a (7 x, 6 y, 5 z), 1 c"},
+		{"due", "1 c, 2 a (5 z, 6 y, 7 x), 3 B, 4 d"},
+	}
+
+	for _, tt := range tests {
+		s, err := todo.ParseSort(tt.sort)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sorted := todos.Clone()
+		sorted.Sort(s)
+		if got := render(sorted); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.sort, got, tt.want)
+		}
+	}
+
+	for _, s := range []string{"size", "text:up", ""} {
+		if _, err := todo.ParseSort(s); err == nil {
+			t.Errorf("%q: expected an error", s)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package todo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	RecurDaily   = "daily"
+	RecurWeekly  = "weekly"
+	RecurMonthly = "monthly"
+	RecurEvery   = "every"
+)
+
+// Recurrence is the rule a repeating todo follows. It is written as
+// "daily", "weekly", "weekly:mon,thu", "monthly" or "every N days". A
+// monthly rule remembers the day of the month it started on as
+// "monthly:31", so that it comes back to it after shorter months.
+type Recurrence struct {
+	Kind     string
+	Weekdays []time.Weekday
+	Days     int
+	// Day is the day of the month a monthly rule falls on, or 0 for the
+	// day of the date it is applied to.
+	Day int
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+func ParseRecurrence(s string) (*Recurrence, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	switch {
+	case s == RecurDaily, s == RecurMonthly, s == RecurWeekly:
+		return &Recurrence{Kind: s}, nil
+	case strings.HasPrefix(s, RecurWeekly+":"):
+		r := &Recurrence{Kind: RecurWeekly}
+		for _, name := range strings.Split(strings.TrimPrefix(s, RecurWeekly+":"), ",") {
+			name = strings.TrimSpace(name)
+			if len(name) > 3 {
+				name = name[:3]
+			}
+			day, ok := weekdayNames[name]
+			if !ok {
+				return nil, fmt.Errorf("invalid weekday %q in recurrence %q", name, s)
+			}
+			r.Weekdays = append(r.Weekdays, day)
+		}
+		return r, nil
+	case strings.HasPrefix(s, RecurMonthly+":"):
+		day, err := strconv.Atoi(strings.TrimPrefix(s, RecurMonthly+":"))
+		if err == nil && day >= 1 && day <= 31 {
+			return &Recurrence{Kind: RecurMonthly, Day: day}, nil
+		}
+	case strings.HasPrefix(s, RecurEvery+" "):
+		fields := strings.Fields(s)
+		if len(fields) == 3 && (fields[2] == "days" || fields[2] == "day") {
+			n, err := strconv.Atoi(fields[1])
+			if err == nil && n > 0 {
+				return &Recurrence{Kind: RecurEvery, Days: n}, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("invalid recurrence %q (use daily, weekly, weekly:mon,thu, monthly or \"every N days\")", s)
+}
+
+func (r Recurrence) String() string {
+	switch r.Kind {
+	case RecurWeekly:
+		if len(r.Weekdays) == 0 {
+			return RecurWeekly
+		}
+		var names []string
+		for _, day := range r.Weekdays {
+			names = append(names, strings.ToLower(day.String()[:3]))
+		}
+		return RecurWeekly + ":" + strings.Join(names, ",")
+	case RecurMonthly:
+		if r.Day == 0 {
+			return RecurMonthly
+		}
+		return fmt.Sprintf("%s:%d", RecurMonthly, r.Day)
+	case RecurEvery:
+		return fmt.Sprintf("every %d days", r.Days)
+	default:
+		return r.Kind
+	}
+}
+
+func (r Recurrence) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *Recurrence) UnmarshalText(text []byte) error {
+	parsed, err := ParseRecurrence(string(text))
+	if err != nil {
+		return err
+	}
+
+	*r = *parsed
+	return nil
+}
+
+// Next returns the first occurrence strictly after from. Monthly rules
+// fall on the last day of months too short for their day.
+func (r Recurrence) Next(from time.Time) time.Time {
+	switch r.Kind {
+	case RecurDaily:
+		return from.AddDate(0, 0, 1)
+	case RecurWeekly:
+		if len(r.Weekdays) == 0 {
+			return from.AddDate(0, 0, 7)
+		}
+		for n := 1; n <= 7; n++ {
+			next := from.AddDate(0, 0, n)
+			for _, day := range r.Weekdays {
+				if next.Weekday() == day {
+					return next
+				}
+			}
+		}
+	case RecurMonthly:
+		day := r.Day
+		if day == 0 {
+			day = from.Day()
+		}
+		y, m, _ := from.Date()
+		// Day 0 of the month after next is the last day of next month.
+		if last := time.Date(y, m+2, 0, 0, 0, 0, 0, from.Location()).Day(); day > last {
+			day = last
+		}
+		return time.Date(y, m+1, day, from.Hour(), from.Minute(), from.Second(), from.Nanosecond(), from.Location())
+	case RecurEvery:
+		return from.AddDate(0, 0, r.Days)
+	}
+
+	return from.AddDate(0, 0, 1)
+}
+
+func (t *Todos) SetRecurrence(id int, r *Recurrence) error {
+	ls, idx, err := t.find(id)
+	if err != nil {
+		return err
+	}
+
+	(*ls)[idx].Recur = r
+
+	return nil
+}
+
+// spawnNext appends the next occurrence of the recurring todo i to ls. The
+// new due date follows the rule from the old one (or from now when there was
+// none) and is moved forward until it lies in the future.
+func spawnNext(ls *Todos, i item, now time.Time, next func() int) {
+	base := i.DueAt
+	if base.IsZero() {
+		base = now
+	}
+	recur := *i.Recur
+	if recur.Kind == RecurMonthly && recur.Day == 0 {
+		recur.Day = base.Day()
+	}
+	due := recur.Next(base)
+	for !due.After(now) {
+		due = recur.Next(due)
+	}
+
+	id := add(ls, i.Task, next)
+	_, idx, _ := ls.find(id)
+	spawned := &(*ls)[idx]
+	spawned.Priority = i.Priority
+	spawned.DueAt = due
+	spawned.Projects = append([]string(nil), i.Projects...)
+	spawned.Tags = append([]string(nil), i.Tags...)
+	spawned.Recur = &recur
+	for _, child := range i.Children {
+		addCopy(&spawned.Children, child, next)
+	}
+}
+
+// addCopy adds an open copy of i and its subtasks to ls under fresh IDs.
+func addCopy(ls *Todos, i item, next func() int) {
+	id := add(ls, i.Task, next)
+	_, idx, _ := ls.find(id)
+	c := &(*ls)[idx]
+	c.Priority = i.Priority
+	c.Projects = append([]string(nil), i.Projects...)
+	c.Tags = append([]string(nil), i.Tags...)
+	for _, child := range i.Children {
+		addCopy(&c.Children, child, next)
+	}
+}
@@ -0,0 +1,131 @@
+package todo_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/example/todo"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"daily", "daily"},
+		{" Weekly ", "weekly"},
+		{"weekly:mon,thu", "weekly:mon,thu"},
+		{"weekly:monday, thursday", "weekly:mon,thu"},
+		{"monthly", "monthly"},
+		{"monthly:31", "monthly:31"},
+		{"every 3 days", "every 3 days"},
+		{"every 1 day", "every 1 days"},
+	}
+
+	for _, tt := range tests {
+		r, err := todo.ParseRecurrence(tt.in)
+		if err != nil {
+			t.Errorf("%q: %v", tt.in, err)
+			continue
+		}
+		if got := r.String(); got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "hourly", "weekly:funday", "monthly:0", "monthly:32", "every 0 days", "every 3 weeks", "every days"} {
+		if _, err := todo.ParseRecurrence(in); err == nil {
+			t.Errorf("%q: expected an error", in)
+		}
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 9, 30, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		rule string
+		from time.Time
+		want time.Time
+	}{
+		{"daily", date(2027, 2, 28), date(2027, 3, 1)},
+		{"weekly", date(2027, 3, 3), date(2027, 3, 10)},
+		// 2027-03-03 is a Wednesday.
+		{"weekly:mon,thu", date(2027, 3, 3), date(2027, 3, 4)},
+		{"weekly:mon,thu", date(2027, 3, 4), date(2027, 3, 8)},
+		{"weekly:wed", date(2027, 3, 3), date(2027, 3, 10)},
+		{"every 10 days", date(2027, 12, 25), date(2028, 1, 4)},
+		{"monthly", date(2027, 1, 15), date(2027, 2, 15)},
+		{"monthly", date(2027, 1, 31), date(2027, 2, 28)},
+		{"monthly", date(2028, 1, 31), date(2028, 2, 29)},
+		{"monthly", date(2027, 12, 31), date(2028, 1, 31)},
+		{"monthly:31", date(2027, 2, 28), date(2027, 3, 31)},
+		{"monthly:31", date(2027, 3, 31), date(2027, 4, 30)},
+		{"monthly:30", date(2027, 1, 30), date(2027, 2, 28)},
+	}
+
+	for _, tt := range tests {
+		r, err := todo.ParseRecurrence(tt.rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := r.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("%s after %s: got %s, want %s", tt.rule, tt.from.Format("2006-01-02"), got, tt.want)
+		}
+	}
+}
+
+func TestCompleteRecurring(t *testing.T) {
+	// Far enough ahead that the due dates are never in the past.
+	date := func(m time.Month, d int) time.Time {
+		return time.Date(2101, m, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		rule string
+		due  time.Time
+		want []time.Time
+	}{
+		{"monthly", date(1, 31), []time.Time{date(2, 28), date(3, 31), date(4, 30)}},
+		{"every 3 days", date(1, 30), []time.Time{date(2, 2), date(2, 5), date(2, 8)}},
+		{"weekly:sat", date(1, 1), []time.Time{date(1, 8), date(1, 15), date(1, 22)}},
+	}
+
+	for _, tt := range tests {
+		r, err := todo.ParseRecurrence(tt.rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		todos := todo.Todos{}
+		id := todos.Add("pay rent +home")
+		todos.AddSub(id, "transfer")
+		todos.SetDue(id, tt.due)
+		todos.SetRecurrence(id, r)
+
+		for _, want := range tt.want {
+			if err := todos.ForceComplete(id); err != nil {
+				t.Fatal(err)
+			}
+			done, spawned := todos[len(todos)-2], todos[len(todos)-1]
+			if done.Recur != nil {
+				t.Errorf("%s: the completed todo kept its rule", tt.rule)
+			}
+			if !spawned.DueAt.Equal(want) {
+				t.Errorf("%s: next due %s, want %s", tt.rule, spawned.DueAt.Format("2006-01-02"), want.Format("2006-01-02"))
+			}
+			if spawned.Done || spawned.Recur == nil || spawned.ID == done.ID {
+				t.Errorf("%s: spawned %+v", tt.rule, spawned)
+			}
+			if !equal(spawned.Projects, []string{"home"}) {
+				t.Errorf("%s: spawned projects %v", tt.rule, spawned.Projects)
+			}
+			if len(spawned.Children) != 1 || spawned.Children[0].Done {
+				t.Errorf("%s: spawned subtasks %+v", tt.rule, spawned.Children)
+			}
+			id = spawned.ID
+		}
+	}
+}
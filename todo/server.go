@@ -0,0 +1,283 @@
+package todo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Server exposes a todo list over HTTP with JSON bodies:
+//
+//	GET    /todos                list all todos
+//	POST   /todos                add a todo
+//	GET    /todos/{id}           get one todo
+//	PATCH  /todos/{id}           edit a todo
+//	POST   /todos/{id}/complete  complete a todo (?force=true for open subtasks)
+//	DELETE /todos/{id}           delete a todo
+//
+// Every request goes through a SharedList, so the server and the command
+// line can be used on the same file side by side.
+type Server struct {
+	List *SharedList
+}
+
+func NewServer(store Storage, path string) *Server {
+	return &Server{List: NewSharedList(store, path)}
+}
+
+type addRequest struct {
+	Task     string
+	Parent   int
+	Priority string
+	Due      time.Time
+}
+
+type editRequest struct {
+	Task     *string
+	Priority *string
+	Due      *time.Time
+}
+
+type errorResponse struct {
+	Error string
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+	parts := strings.Split(path, "/")
+	if parts[0] != "todos" || len(parts) > 3 {
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+
+	if len(parts) == 1 {
+		switch r.Method {
+		case http.MethodGet:
+			s.list(w)
+		case http.MethodPost:
+			s.add(w, r)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		}
+		return
+	}
+
+	id, err := strconv.Atoi(parts[1])
+	if err != nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%w %s", ErrNotFound, parts[1]))
+		return
+	}
+
+	if len(parts) == 3 {
+		if parts[2] != "complete" {
+			writeError(w, http.StatusNotFound, errors.New("not found"))
+			return
+		}
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+			return
+		}
+		s.complete(w, r, id)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.get(w, id)
+	case http.MethodPatch:
+		s.edit(w, r, id)
+	case http.MethodDelete:
+		s.delete(w, id)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	}
+}
+
+func (s *Server) list(w http.ResponseWriter) {
+	var todos Todos
+	err := s.List.View(func(t *Todos) error {
+		todos = *t
+		return nil
+	})
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, todos)
+}
+
+func (s *Server) get(w http.ResponseWriter, id int) {
+	var found item
+	err := s.List.View(func(t *Todos) error {
+		ls, idx, err := t.find(id)
+		if err != nil {
+			return err
+		}
+		found = (*ls)[idx]
+		return nil
+	})
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, found)
+}
+
+func (s *Server) add(w http.ResponseWriter, r *http.Request) {
+	var req addRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if strings.TrimSpace(req.Task) == "" {
+		writeError(w, http.StatusBadRequest, ErrEmpty)
+		return
+	}
+	priority, err := ParsePriority(req.Priority)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var added item
+	err = s.List.Update(func(t *List) (string, error) {
+		id := 0
+		if req.Parent > 0 {
+			var err error
+			if id, err = t.AddSub(req.Parent, req.Task); err != nil {
+				return "", err
+			}
+		} else {
+			id = t.Add(req.Task)
+		}
+		t.SetPriority(id, priority)
+		t.SetDue(id, req.Due)
+
+		ls, idx, _ := t.find(id)
+		added = (*ls)[idx]
+		return fmt.Sprintf("add %d: %s", id, req.Task), nil
+	})
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, added)
+}
+
+func (s *Server) edit(w http.ResponseWriter, r *http.Request, id int) {
+	var req editRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var priority Priority
+	if req.Priority != nil {
+		var err error
+		if priority, err = ParsePriority(*req.Priority); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	var edited item
+	err := s.List.Update(func(t *List) (string, error) {
+		if req.Task != nil {
+			if err := t.Edit(id, *req.Task); err != nil {
+				return "", err
+			}
+		}
+		if req.Priority != nil {
+			if err := t.SetPriority(id, priority); err != nil {
+				return "", err
+			}
+		}
+		if req.Due != nil {
+			if err := t.SetDue(id, *req.Due); err != nil {
+				return "", err
+			}
+		}
+
+		ls, idx, err := t.find(id)
+		if err != nil {
+			return "", err
+		}
+		edited = (*ls)[idx]
+		return fmt.Sprintf("edit %d: %s", id, edited.Task), nil
+	})
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, edited)
+}
+
+func (s *Server) complete(w http.ResponseWriter, r *http.Request, id int) {
+	force, _ := strconv.ParseBool(r.URL.Query().Get("force"))
+
+	var completed item
+	err := s.List.Update(func(t *List) (string, error) {
+		completeTodo := t.Complete
+		if force {
+			completeTodo = t.ForceComplete
+		}
+		if err := completeTodo(id); err != nil {
+			return "", err
+		}
+
+		ls, idx, _ := t.find(id)
+		completed = (*ls)[idx]
+		return fmt.Sprintf("complete %d", id), nil
+	})
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, completed)
+}
+
+func (s *Server) delete(w http.ResponseWriter, id int) {
+	err := s.List.Update(func(t *List) (string, error) {
+		return fmt.Sprintf("delete %d", id), t.Delete(id)
+	})
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// statusFor maps an error from update to an HTTP status.
+func statusFor(err error) int {
+	var reqErr requestError
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrEmpty):
+		return http.StatusBadRequest
+	case errors.As(err, &reqErr):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
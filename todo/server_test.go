@@ -0,0 +1,166 @@
+package todo_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/example/todo"
+)
+
+type serverItem struct {
+	ID       int
+	Task     string
+	Done     bool
+	Priority string
+	Children []serverItem
+}
+
+func newTestServer(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), ".todos.json")
+	srv := httptest.NewServer(todo.NewServer(&todo.JSONFile{Path: path}, path))
+	t.Cleanup(srv.Close)
+
+	return srv, path
+}
+
+func do(t *testing.T, method, url string, body interface{}, want int, out interface{}) {
+	t.Helper()
+
+	if err := request(method, url, body, want, out); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// request is do for goroutines other than the test's, which must not call
+// t.Fatal.
+func request(method, url string, body interface{}, want int, out interface{}) error {
+	var r bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&r).Encode(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, url, &r)
+	if err != nil {
+		return err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != want {
+		var e struct{ Error string }
+		json.NewDecoder(res.Body).Decode(&e)
+		return fmt.Errorf("%s %s: got status %d (%s), want %d", method, url, res.StatusCode, e.Error, want)
+	}
+	if out != nil {
+		return json.NewDecoder(res.Body).Decode(out)
+	}
+
+	return nil
+}
+
+func TestServer(t *testing.T) {
+	srv, path := newTestServer(t)
+	url := srv.URL + "/todos"
+
+	var added serverItem
+	do(t, http.MethodPost, url, map[string]string{"Task": "ship it", "Priority": "high"}, http.StatusCreated, &added)
+	if added.ID != 1 || added.Task != "ship it" || added.Priority != "high" {
+		t.Fatalf("got %+v", added)
+	}
+
+	var sub serverItem
+	do(t, http.MethodPost, url, map[string]interface{}{"Task": "tests", "Parent": added.ID}, http.StatusCreated, &sub)
+
+	do(t, http.MethodPost, fmt.Sprintf("%s/%d/complete", url, added.ID), nil, http.StatusConflict, nil)
+	do(t, http.MethodPost, fmt.Sprintf("%s/%d/complete", url, sub.ID), nil, http.StatusOK, nil)
+
+	var completed serverItem
+	do(t, http.MethodPost, fmt.Sprintf("%s/%d/complete", url, added.ID), nil, http.StatusOK, &completed)
+	if !completed.Done {
+		t.Errorf("todo not completed: %+v", completed)
+	}
+
+	var edited serverItem
+	do(t, http.MethodPatch, fmt.Sprintf("%s/%d", url, added.ID), map[string]string{"Task": "shipped"}, http.StatusOK, &edited)
+	do(t, http.MethodPatch, fmt.Sprintf("%s/%d", url, added.ID), map[string]string{"Task": " "}, http.StatusBadRequest, nil)
+	do(t, http.MethodPatch, fmt.Sprintf("%s/%d", url, added.ID), map[string]string{"Priority": "urgent"}, http.StatusBadRequest, nil)
+	do(t, http.MethodPatch, fmt.Sprintf("%s/%d", url, 99), map[string]string{"Task": "nope"}, http.StatusNotFound, nil)
+	if edited.Task != "shipped" {
+		t.Errorf("got task %q, want shipped", edited.Task)
+	}
+
+	var list []serverItem
+	do(t, http.MethodGet, url, nil, http.StatusOK, &list)
+	if len(list) != 1 || len(list[0].Children) != 1 {
+		t.Fatalf("got %+v", list)
+	}
+
+	do(t, http.MethodDelete, fmt.Sprintf("%s/%d", url, added.ID), nil, http.StatusNoContent, nil)
+	do(t, http.MethodGet, fmt.Sprintf("%s/%d", url, added.ID), nil, http.StatusNotFound, nil)
+	do(t, http.MethodDelete, fmt.Sprintf("%s/%d", url, added.ID), nil, http.StatusNotFound, nil)
+	do(t, http.MethodPost, url, map[string]string{"Task": " "}, http.StatusBadRequest, nil)
+	do(t, http.MethodPut, url, nil, http.StatusMethodNotAllowed, nil)
+
+	// The server's changes land in the file and the journal like the CLI's do.
+	todos := &todo.Todos{}
+	if err := todos.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if len(*todos) != 0 {
+		t.Errorf("got %d todos in the file, want 0", len(*todos))
+	}
+
+	journal, err := todo.LoadJournal(todo.JournalPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := journal.Undo(todos); err != nil {
+		t.Fatal(err)
+	}
+	if len(*todos) != 1 || (*todos)[0].Task != "shipped" {
+		t.Errorf("undo restored %+v", *todos)
+	}
+}
+
+func TestServerConcurrentAdd(t *testing.T) {
+	srv, _ := newTestServer(t)
+	url := srv.URL + "/todos"
+	const clients = 20
+
+	var wg sync.WaitGroup
+	errs := make(chan error, clients)
+	for n := 0; n < clients; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			if err := request(http.MethodPost, url, map[string]string{"Task": fmt.Sprintf("task %d", n)}, http.StatusCreated, nil); err != nil {
+				errs <- err
+			}
+		}(n)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	var list []serverItem
+	do(t, http.MethodGet, url, nil, http.StatusOK, &list)
+	if len(list) != clients {
+		t.Fatalf("got %d todos, want %d", len(list), clients)
+	}
+}
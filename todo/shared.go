@@ -0,0 +1,89 @@
+package todo
+
+import "sync"
+
+// SharedList is a list kept in Storage that other processes may change at
+// any time. Each View or Update loads it afresh under the advisory lock.
+type SharedList struct {
+	Storage Storage
+	// Path is the data file, used for the advisory lock and the journal.
+	// Leave it empty to skip both.
+	Path string
+	// Hooks run on every change, if set.
+	Hooks *Hooks
+
+	mu sync.Mutex
+}
+
+func NewSharedList(store Storage, path string) *SharedList {
+	return &SharedList{Storage: store, Path: path}
+}
+
+// requestError wraps an error returned by the function given to Update,
+// as opposed to a failure to load or store the list.
+type requestError struct {
+	error
+}
+
+func (e requestError) Unwrap() error {
+	return e.error
+}
+
+// View runs fn on the current list without changing it.
+func (l *SharedList) View(fn func(t *Todos) error) error {
+	return l.Update(func(t *List) (string, error) { return "", fn(&t.Todos) })
+}
+
+// Update loads the list and runs fn on it. If fn succeeds and describes the
+// change it made, the list is stored and the change journaled. Updates are
+// serialized within the process and locked against other processes.
+func (l *SharedList) Update(fn func(t *List) (string, error)) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.Path != "" {
+		unlock, err := Lock(l.Path)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	todos := &List{}
+	if err := l.Storage.Load(todos); err != nil {
+		return err
+	}
+	before := todos.Clone()
+
+	description, err := fn(todos)
+	if err != nil {
+		return requestError{err}
+	}
+	if description == "" {
+		return nil
+	}
+	if err := l.Hooks.Pre(before, todos.Todos); err != nil {
+		return requestError{err}
+	}
+
+	if err := l.Storage.Store(todos); err != nil {
+		return err
+	}
+
+	if l.Path != "" {
+		journal, err := LoadJournal(JournalPath(l.Path))
+		if err != nil {
+			return err
+		}
+		if err := journal.Record(description, before, todos.Todos); err != nil {
+			return err
+		}
+		if err := journal.Store(); err != nil {
+			return err
+		}
+	}
+
+	l.Hooks.Post(before, todos.Todos)
+
+	return nil
+}
@@ -0,0 +1,222 @@
+package todo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alexeyco/simpletable"
+)
+
+// oldestShown is how many of the oldest open todos Stats lists.
+const oldestShown = 5
+
+// burndownWidth is the length of the longest bar of the burndown chart.
+const burndownWidth = 40
+
+// Stats describes the work done between From and To.
+type Stats struct {
+	From time.Time
+	To   time.Time
+
+	// Days has an entry for every day of the window.
+	Days  []DayStats
+	Weeks []WeekStats
+
+	Completed              int
+	AverageLeadTimeSeconds int64
+
+	Oldest Todos
+}
+
+// DayStats counts the todos completed on a day and those still open at its end.
+type DayStats struct {
+	Day       string
+	Completed int
+	Open      int
+}
+
+// WeekStats counts the todos completed in an ISO week, written as 2026-W01.
+type WeekStats struct {
+	Week      string
+	Completed int
+}
+
+// Stats works out statistics for the window from from up to to, from the
+// times todos were created and completed. Subtasks count like other todos,
+// and so do the todos moved to archive, unless they are back in the list.
+func (t *Todos) Stats(archive Todos, from, to time.Time) Stats {
+	s := Stats{From: from, To: to}
+
+	var all Todos
+	seen := map[int]bool{}
+	for _, ls := range []*Todos{t, &archive} {
+		ls.walk(func(_ int, i *item) {
+			if seen[i.ID] {
+				return
+			}
+			seen[i.ID] = true
+			flat := *i
+			flat.Children = nil
+			all = append(all, flat)
+		})
+	}
+
+	weeks := map[string]int{}
+	var leadTime time.Duration
+	for _, i := range all {
+		if !i.Done || i.CompletedAt.Before(from) || !i.CompletedAt.Before(to) {
+			continue
+		}
+		s.Completed++
+		leadTime += i.CompletedAt.Sub(i.CreatedAt)
+		year, week := i.CompletedAt.ISOWeek()
+		weeks[fmt.Sprintf("%d-W%02d", year, week)]++
+	}
+	if s.Completed > 0 {
+		s.AverageLeadTimeSeconds = int64((leadTime / time.Duration(s.Completed)).Seconds())
+	}
+
+	for name, n := range weeks {
+		s.Weeks = append(s.Weeks, WeekStats{Week: name, Completed: n})
+	}
+	sort.Slice(s.Weeks, func(a, b int) bool {
+		return s.Weeks[a].Week < s.Weeks[b].Week
+	})
+
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		end := day.AddDate(0, 0, 1)
+		d := DayStats{Day: day.Format("2006-01-02")}
+		for _, i := range all {
+			if i.Done && !i.CompletedAt.Before(day) && i.CompletedAt.Before(end) {
+				d.Completed++
+			}
+			if i.CreatedAt.Before(end) && (!i.Done || !i.CompletedAt.Before(end)) {
+				d.Open++
+			}
+		}
+		s.Days = append(s.Days, d)
+	}
+
+	s.Oldest = Todos{}
+	for _, i := range all {
+		if !i.Done {
+			s.Oldest = append(s.Oldest, i)
+		}
+	}
+	sort.SliceStable(s.Oldest, func(a, b int) bool {
+		return s.Oldest[a].CreatedAt.Before(s.Oldest[b].CreatedAt)
+	})
+	if len(s.Oldest) > oldestShown {
+		s.Oldest = s.Oldest[:oldestShown]
+	}
+
+	return s
+}
+
+// WriteJSON writes the statistics as indented JSON.
+func (s Stats) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(s)
+}
+
+// Print prints the burndown of the window, the completed todos per week and
+// the oldest open todos.
+func (s Stats) Print() {
+	now := time.Now()
+
+	maxOpen := 0
+	for _, d := range s.Days {
+		if d.Open > maxOpen {
+			maxOpen = d.Open
+		}
+	}
+
+	burndown := simpletable.New()
+	burndown.Header = &simpletable.Header{
+		Cells: []*simpletable.Cell{
+			{Align: simpletable.AlignCenter, Text: "Day"},
+			{Align: simpletable.AlignCenter, Text: "Done"},
+			{Align: simpletable.AlignCenter, Text: "Open"},
+			{Align: simpletable.AlignLeft, Text: "Burndown"},
+		},
+	}
+	var cells [][]*simpletable.Cell
+	for _, d := range s.Days {
+		bar := ""
+		if maxOpen > 0 {
+			bar = strings.Repeat("█", (d.Open*burndownWidth+maxOpen-1)/maxOpen)
+		}
+		cells = append(cells, []*simpletable.Cell{
+			{Text: d.Day},
+			{Align: simpletable.AlignRight, Text: green(fmt.Sprintf("%d", d.Completed))},
+			{Align: simpletable.AlignRight, Text: fmt.Sprintf("%d", d.Open)},
+			{Text: blue(bar)},
+		})
+	}
+	burndown.Body = &simpletable.Body{Cells: cells}
+
+	leadTime := "-"
+	if s.Completed > 0 {
+		leadTime = formatDays(time.Duration(s.AverageLeadTimeSeconds) * time.Second)
+	}
+	burndown.Footer = &simpletable.Footer{Cells: []*simpletable.Cell{
+		{Align: simpletable.AlignCenter, Span: 4, Text: red(fmt.Sprintf("%d todos completed, taking %s on average", s.Completed, leadTime))},
+	}}
+	burndown.SetStyle(simpletable.StyleUnicode)
+	burndown.Println()
+
+	weeks := simpletable.New()
+	weeks.Header = &simpletable.Header{
+		Cells: []*simpletable.Cell{
+			{Align: simpletable.AlignCenter, Text: "Week"},
+			{Align: simpletable.AlignCenter, Text: "Done"},
+		},
+	}
+	cells = nil
+	for _, w := range s.Weeks {
+		cells = append(cells, []*simpletable.Cell{
+			{Text: w.Week},
+			{Align: simpletable.AlignRight, Text: green(fmt.Sprintf("%d", w.Completed))},
+		})
+	}
+	weeks.Body = &simpletable.Body{Cells: cells}
+	weeks.SetStyle(simpletable.StyleUnicode)
+	weeks.Println()
+
+	oldest := simpletable.New()
+	oldest.Header = &simpletable.Header{
+		Cells: []*simpletable.Cell{
+			{Align: simpletable.AlignCenter, Text: "#"},
+			{Align: simpletable.AlignCenter, Text: "Oldest open todos"},
+			{Align: simpletable.AlignCenter, Text: "Age"},
+		},
+	}
+	cells = nil
+	for _, i := range s.Oldest {
+		cells = append(cells, []*simpletable.Cell{
+			{Text: fmt.Sprintf("%d", i.ID)},
+			{Text: blue(i.Task)},
+			{Align: simpletable.AlignRight, Text: formatDays(now.Sub(i.CreatedAt))},
+		})
+	}
+	oldest.Body = &simpletable.Body{Cells: cells}
+	oldest.SetStyle(simpletable.StyleUnicode)
+	oldest.Println()
+}
+
+// formatDays writes a long duration in days and hours, e.g. "3d 4h".
+func formatDays(d time.Duration) string {
+	if d < 24*time.Hour {
+		return formatDuration(d)
+	}
+
+	d = d.Round(time.Hour)
+
+	return fmt.Sprintf("%dd %dh", int(d.Hours())/24, int(d.Hours())%24)
+}
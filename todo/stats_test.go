@@ -0,0 +1,73 @@
+package todo_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/example/todo"
+)
+
+func TestStats(t *testing.T) {
+	todos := decode(t, `[
+		{"ID":1,"Task":"a","Done":true,"CreatedAt":"2026-10-01T09:00:00Z","CompletedAt":"2026-10-05T09:00:00Z"},
+		{"ID":4,"Task":"d","CreatedAt":"2026-10-04T00:00:00Z","Children":[
+			{"ID":3,"Task":"c","Done":true,"CreatedAt":"2026-10-06T00:00:00Z","CompletedAt":"2026-10-12T00:00:00Z"}]},
+		{"ID":5,"Task":"e","Done":true,"CreatedAt":"2026-10-10T00:00:00Z","CompletedAt":"2026-10-13T00:00:00Z"}]`)
+	// Todo 1 is in the archive as well, as archiving it was undone.
+	archive := decode(t, `[
+		{"ID":1,"Task":"a","Done":true,"CreatedAt":"2026-10-01T09:00:00Z","CompletedAt":"2026-10-05T09:00:00Z"},
+		{"ID":2,"Task":"b","Done":true,"CreatedAt":"2026-10-05T10:00:00Z","CompletedAt":"2026-10-05T22:00:00Z"},
+		{"ID":6,"Task":"g","Done":true,"CreatedAt":"2026-09-01T00:00:00Z","CompletedAt":"2026-10-04T23:59:00Z"}]`)
+
+	from := time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 8)
+	s := todos.Stats(archive, from, to)
+
+	if s.Completed != 3 {
+		t.Errorf("got %d completed, want 3", s.Completed)
+	}
+	// 4 days, 12 hours and 6 days.
+	if want := int64((3*24 + 12) * time.Hour / time.Second); s.AverageLeadTimeSeconds != want {
+		t.Errorf("got an average lead time of %ds, want %ds", s.AverageLeadTimeSeconds, want)
+	}
+
+	wantDays := []todo.DayStats{
+		{"2026-10-05", 2, 1},
+		{"2026-10-06", 0, 2},
+		{"2026-10-07", 0, 2},
+		{"2026-10-08", 0, 2},
+		{"2026-10-09", 0, 2},
+		{"2026-10-10", 0, 3},
+		{"2026-10-11", 0, 3},
+		{"2026-10-12", 1, 2},
+	}
+	if len(s.Days) != len(wantDays) {
+		t.Fatalf("got %d days, want %d", len(s.Days), len(wantDays))
+	}
+	for idx, want := range wantDays {
+		if s.Days[idx] != want {
+			t.Errorf("got %+v, want %+v", s.Days[idx], want)
+		}
+	}
+
+	wantWeeks := []todo.WeekStats{{"2026-W41", 2}, {"2026-W42", 1}}
+	if len(s.Weeks) != len(wantWeeks) || s.Weeks[0] != wantWeeks[0] || s.Weeks[1] != wantWeeks[1] {
+		t.Errorf("got weeks %+v, want %+v", s.Weeks, wantWeeks)
+	}
+
+	if got := tasks(s.Oldest); !equal(got, []string{"d"}) {
+		t.Errorf("got oldest open %v, want [d]", got)
+	}
+}
+
+func TestStatsEmpty(t *testing.T) {
+	from := time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC)
+	s := (&todo.Todos{}).Stats(nil, from, from.AddDate(0, 0, 2))
+
+	if s.Completed != 0 || s.AverageLeadTimeSeconds != 0 || len(s.Weeks) != 0 || len(s.Oldest) != 0 {
+		t.Errorf("got %+v", s)
+	}
+	if len(s.Days) != 2 {
+		t.Errorf("got %d days, want 2", len(s.Days))
+	}
+}
@@ -0,0 +1,189 @@
+package todo
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	_ "modernc.org/sqlite"
+)
+
+// Storage is a place the todo list can be loaded from and stored to.
+type Storage interface {
+	Load(l *List) error
+	Store(l *List) error
+}
+
+const (
+	BackendJSON   = "json"
+	BackendSQLite = "sqlite"
+)
+
+func NewStorage(backend, path string) (Storage, error) {
+	switch backend {
+	case BackendJSON, "":
+		return &JSONFile{Path: path}, nil
+	case BackendSQLite:
+		return &SQLite{Path: path}, nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q (use %s or %s)", backend, BackendJSON, BackendSQLite)
+	}
+}
+
+// JSONFile keeps the whole list as a JSON array in a single file.
+type JSONFile struct {
+	Path string
+}
+
+func (f *JSONFile) Load(l *List) error {
+	return l.Load(f.Path)
+}
+
+func (f *JSONFile) Store(l *List) error {
+	return l.Store(f.Path)
+}
+
+// SQLite keeps one row per todo in an embedded SQLite database, and the
+// NextID of the list in a meta table.
+type SQLite struct {
+	Path string
+}
+
+const sqliteSchema = `CREATE TABLE IF NOT EXISTS todos (
+	id       INTEGER PRIMARY KEY,
+	position INTEGER NOT NULL,
+	data     TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS meta (
+	key   TEXT PRIMARY KEY,
+	value INTEGER NOT NULL
+)`
+
+func (s *SQLite) open() (*sql.DB, error) {
+	db, err := sql.Open("sqlite", s.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func (s *SQLite) Load(l *List) error {
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT data FROM todos ORDER BY position")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	ls := Todos{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return err
+		}
+
+		var i item
+		if err := json.Unmarshal([]byte(data), &i); err != nil {
+			return err
+		}
+		ls = append(ls, i)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	ls.assignIDs()
+	list := List{Todos: ls}
+
+	// Databases written before NextID was kept may have handed out IDs
+	// that only the archive still holds.
+	err = db.QueryRow("SELECT value FROM meta WHERE key = 'next_id'").Scan(&list.NextID)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = list.reserveArchived(ArchivePath(s.Path))
+	}
+	if err != nil {
+		return err
+	}
+	list.NextID = list.next()
+	*l = list
+
+	return nil
+}
+
+func (s *SQLite) Store(l *List) error {
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM todos"); err != nil {
+		return err
+	}
+
+	for position, i := range l.Todos {
+		data, err := json.Marshal(i)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec("INSERT INTO todos (id, position, data) VALUES (?, ?, ?)", i.ID, position, string(data))
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec("INSERT OR REPLACE INTO meta (key, value) VALUES ('next_id', ?)", l.next()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ErrNotEmpty is returned by Migrate for a destination that holds todos.
+var ErrNotEmpty = errors.New("the destination already holds todos")
+
+// Migrate copies every todo from one storage to another. A destination that
+// holds todos already is refused unless force is set, in which case its
+// todos are replaced; their IDs are still not handed out again.
+func Migrate(from, to Storage, force bool) (int, error) {
+	existing := &List{}
+	if err := to.Load(existing); err != nil {
+		return 0, err
+	}
+	if len(existing.Todos) > 0 && !force {
+		return 0, fmt.Errorf("%w (%d todos)", ErrNotEmpty, len(existing.Todos))
+	}
+
+	todos := &List{}
+	if err := from.Load(todos); err != nil {
+		return 0, err
+	}
+	if next := existing.next(); next > todos.NextID {
+		todos.NextID = next
+	}
+
+	if err := to.Store(todos); err != nil {
+		return 0, err
+	}
+
+	return len(todos.Todos), nil
+}
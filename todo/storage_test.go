@@ -0,0 +1,88 @@
+package todo_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/example/todo"
+)
+
+func TestMigrateRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	jsonFile := &todo.JSONFile{Path: filepath.Join(dir, "todos.json")}
+	sqlite := &todo.SQLite{Path: filepath.Join(dir, "todos.db")}
+
+	l := &todo.List{}
+	l.Add("first")
+	parent := l.Add("second")
+	sub, _ := l.AddSub(parent, "sub")
+	l.AddSub(sub, "subsub")
+	l.Delete(l.Add("deleted"))
+	l.Add("third")
+	l.Move(6, 1)
+	if err := jsonFile.Store(l); err != nil {
+		t.Fatal(err)
+	}
+	want := render(l.Todos)
+
+	if n, err := todo.Migrate(jsonFile, sqlite, false); err != nil || n != 3 {
+		t.Fatalf("Migrate = %d, %v, want 3 todos", n, err)
+	}
+	back := &todo.JSONFile{Path: filepath.Join(dir, "back.json")}
+	if _, err := todo.Migrate(sqlite, back, false); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, store := range []todo.Storage{sqlite, back} {
+		got := &todo.List{}
+		if err := store.Load(got); err != nil {
+			t.Fatal(err)
+		}
+		if render(got.Todos) != want {
+			t.Errorf("%T: got %s, want %s", store, render(got.Todos), want)
+		}
+		if got.NextID != 7 {
+			t.Errorf("%T: got NextID %d, want 7", store, got.NextID)
+		}
+	}
+}
+
+func TestMigrateNonEmpty(t *testing.T) {
+	dir := t.TempDir()
+	from := &todo.JSONFile{Path: filepath.Join(dir, "todos.json")}
+	to := &todo.SQLite{Path: filepath.Join(dir, "todos.db")}
+
+	l := &todo.List{}
+	l.Add("from json")
+	if err := from.Store(l); err != nil {
+		t.Fatal(err)
+	}
+	existing := &todo.List{}
+	existing.Add("in sqlite")
+	existing.Add("also in sqlite")
+	if err := to.Store(existing); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := todo.Migrate(from, to, false); !errors.Is(err, todo.ErrNotEmpty) {
+		t.Errorf("got error %v, want ErrNotEmpty", err)
+	}
+	got := &todo.List{}
+	to.Load(got)
+	if !equal(tasks(got.Todos), []string{"in sqlite", "also in sqlite"}) {
+		t.Errorf("refused migration changed the destination to %v", tasks(got.Todos))
+	}
+
+	if _, err := todo.Migrate(from, to, true); err != nil {
+		t.Fatal(err)
+	}
+	got = &todo.List{}
+	to.Load(got)
+	if !equal(tasks(got.Todos), []string{"from json"}) {
+		t.Errorf("got %v after a forced migration", tasks(got.Todos))
+	}
+	if id := got.Add("new"); id != 3 {
+		t.Errorf("new todo got ID %d, want 3 past the replaced todos", id)
+	}
+}
@@ -0,0 +1,182 @@
+package todo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// parseTags picks the +project and @context tokens out of a task's text.
+func parseTags(task string) (projects, tags []string) {
+	for _, word := range strings.Fields(task) {
+		switch {
+		case len(word) > 1 && word[0] == '+':
+			projects = appendTag(projects, word[1:])
+		case len(word) > 1 && word[0] == '@':
+			tags = appendTag(tags, word[1:])
+		}
+	}
+
+	return projects, tags
+}
+
+// untagged is the text of a task without its +project and @context tokens.
+func untagged(task string) string {
+	var words []string
+	for _, word := range strings.Fields(task) {
+		if len(word) > 1 && (word[0] == '+' || word[0] == '@') {
+			continue
+		}
+		words = append(words, word)
+	}
+
+	return strings.Join(words, " ")
+}
+
+// appendTag adds tag to list unless it is empty or already there.
+func appendTag(list []string, tag string) []string {
+	tag = strings.TrimLeft(strings.TrimSpace(tag), "+@")
+	if tag == "" || hasTag(list, tag) {
+		return list
+	}
+
+	return append(list, tag)
+}
+
+func hasTag(list []string, tag string) bool {
+	for _, t := range list {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sameTags reports whether a and b hold the same tags in any order.
+func sameTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, tag := range a {
+		if !hasTag(b, tag) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// replaceTags drops the tags in removed from list and appends those in added.
+func replaceTags(list, removed, added []string) []string {
+	var kept []string
+	for _, tag := range list {
+		if !hasTag(removed, tag) {
+			kept = append(kept, tag)
+		}
+	}
+	for _, tag := range added {
+		kept = appendTag(kept, tag)
+	}
+
+	return kept
+}
+
+func (t *Todos) AddTags(id int, projects, tags []string) error {
+	ls, idx, err := t.find(id)
+	if err != nil {
+		return err
+	}
+
+	i := &(*ls)[idx]
+	for _, p := range projects {
+		i.Projects = appendTag(i.Projects, p)
+	}
+	for _, tag := range tags {
+		i.Tags = appendTag(i.Tags, tag)
+	}
+
+	return nil
+}
+
+// Filter selects the todos carrying every listed project and tag and,
+// if Text is set, containing it in their text regardless of case. A set
+// CompletedFrom or CompletedTo limits it to todos completed in that range,
+// from inclusive and to exclusive. Query adds any further conditions.
+type Filter struct {
+	Projects      []string
+	Tags          []string
+	Text          string
+	CompletedFrom time.Time
+	CompletedTo   time.Time
+	Query         Query
+}
+
+func (f Filter) Match(i item) bool {
+	if !f.Query.Match(i) {
+		return false
+	}
+	if !f.CompletedFrom.IsZero() && (!i.Done || i.CompletedAt.Before(f.CompletedFrom)) {
+		return false
+	}
+	if !f.CompletedTo.IsZero() && (!i.Done || !i.CompletedAt.Before(f.CompletedTo)) {
+		return false
+	}
+	if f.Text != "" && !strings.Contains(strings.ToLower(i.Task), strings.ToLower(f.Text)) {
+		return false
+	}
+	for _, p := range f.Projects {
+		if !hasTag(i.Projects, strings.TrimLeft(p, "+")) {
+			return false
+		}
+	}
+	for _, tag := range f.Tags {
+		if !hasTag(i.Tags, strings.TrimLeft(tag, "@")) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (f Filter) String() string {
+	var words []string
+	for _, p := range f.Projects {
+		words = append(words, "+"+strings.TrimLeft(p, "+"))
+	}
+	for _, tag := range f.Tags {
+		words = append(words, "@"+strings.TrimLeft(tag, "@"))
+	}
+	if f.Text != "" {
+		words = append(words, fmt.Sprintf("%q", f.Text))
+	}
+	if q := f.Query.String(); q != "" {
+		words = append(words, q)
+	}
+	if !f.CompletedFrom.IsZero() {
+		words = append(words, "completed from "+f.CompletedFrom.Format(time.RFC822))
+	}
+	if !f.CompletedTo.IsZero() {
+		words = append(words, "completed before "+f.CompletedTo.Format(time.RFC822))
+	}
+
+	return strings.Join(words, " ")
+}
+
+// Select returns the matching todos, subtasks included, as a flat list.
+func (t *Todos) Select(f Filter) Todos {
+	selected := Todos{}
+	t.walk(func(_ int, i *item) {
+		if f.Match(*i) {
+			flat := *i
+			flat.Children = nil
+			selected = append(selected, flat)
+		}
+	})
+
+	return selected
+}
+
+func formatTags(i item) string {
+	return Filter{Projects: i.Projects, Tags: i.Tags}.String()
+}
@@ -0,0 +1,252 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/alexeyco/simpletable"
+)
+
+// Interval is a stretch of work on a todo. It is still running while Stop
+// is zero.
+type Interval struct {
+	Start time.Time
+	Stop  time.Time
+}
+
+func (iv Interval) Running() bool {
+	return iv.Stop.IsZero()
+}
+
+// Elapsed is the time tracked on the todo, counting a running interval up to now.
+func (i item) Elapsed(now time.Time) time.Duration {
+	var total time.Duration
+	for _, iv := range i.Intervals {
+		stop := iv.Stop
+		if iv.Running() {
+			stop = now
+		}
+		total += stop.Sub(iv.Start)
+	}
+
+	return total
+}
+
+// Running reports whether time is being tracked on the todo.
+func (i item) Running() bool {
+	return len(i.Intervals) > 0 && i.Intervals[len(i.Intervals)-1].Running()
+}
+
+// stop ends the running interval of the todo, if there is one.
+func (i *item) stop(now time.Time) {
+	if i.Running() {
+		i.Intervals[len(i.Intervals)-1].Stop = now
+	}
+}
+
+// Start begins tracking time on a todo. Only one todo runs at a time, so
+// the one running before is stopped.
+func (t *Todos) Start(id int) error {
+	ls, idx, err := t.find(id)
+	if err != nil {
+		return err
+	}
+	if (*ls)[idx].Done {
+		return fmt.Errorf("todo %d is already completed", id)
+	}
+	if (*ls)[idx].Running() {
+		return fmt.Errorf("todo %d is already running", id)
+	}
+
+	now := time.Now()
+	t.walk(func(_ int, i *item) {
+		i.stop(now)
+	})
+
+	i := &(*ls)[idx]
+	i.Intervals = append(i.Intervals, Interval{Start: now})
+
+	return nil
+}
+
+// Stop stops tracking time on the running todo and returns its ID.
+func (t *Todos) Stop() (int, error) {
+	id, ok := t.Running()
+	if !ok {
+		return 0, errors.New("no todo is running")
+	}
+
+	ls, idx, _ := t.find(id)
+	(*ls)[idx].stop(time.Now())
+
+	return id, nil
+}
+
+// Running returns the ID of the todo time is being tracked on, if any.
+func (t *Todos) Running() (int, bool) {
+	id := 0
+	t.walk(func(_ int, i *item) {
+		if i.Running() {
+			id = i.ID
+		}
+	})
+
+	return id, id != 0
+}
+
+// ReportLine is the time tracked on one task, tag or day.
+type ReportLine struct {
+	Name  string
+	Total time.Duration
+}
+
+// Report totals the time tracked between From and To.
+type Report struct {
+	From  time.Time
+	To    time.Time
+	Tasks []ReportLine
+	Tags  []ReportLine
+	Days  []ReportLine
+	Total time.Duration
+}
+
+// Report totals the time tracked from from up to to, per task, per tag and
+// per day, on the todos in the list and those moved to archive. A zero from
+// or to leaves that end of the range open; intervals reaching outside the
+// range only count the part inside it.
+func (t *Todos) Report(archive Todos, from, to time.Time) Report {
+	r := Report{From: from, To: to}
+	now := time.Now()
+	tags := map[string]time.Duration{}
+	days := map[string]time.Duration{}
+
+	// A todo back in the list after archiving it was undone counts once.
+	seen := map[int]bool{}
+	visit := func(_ int, i *item) {
+		if seen[i.ID] {
+			return
+		}
+		seen[i.ID] = true
+
+		var task time.Duration
+		for _, iv := range i.Intervals {
+			start, stop := iv.Start, iv.Stop
+			if iv.Running() {
+				stop = now
+			}
+			if !from.IsZero() && start.Before(from) {
+				start = from
+			}
+			if !to.IsZero() && stop.After(to) {
+				stop = to
+			}
+			if !stop.After(start) {
+				continue
+			}
+
+			task += stop.Sub(start)
+
+			// Work past midnight counts towards the day it was done on.
+			for start.Before(stop) {
+				y, m, d := start.Date()
+				midnight := time.Date(y, m, d+1, 0, 0, 0, 0, start.Location())
+				end := stop
+				if midnight.Before(end) {
+					end = midnight
+				}
+				days[start.Format("2006-01-02")] += end.Sub(start)
+				start = end
+			}
+		}
+		if task == 0 {
+			return
+		}
+
+		r.Tasks = append(r.Tasks, ReportLine{Name: fmt.Sprintf("%d %s", i.ID, i.Task), Total: task})
+		r.Total += task
+		for _, p := range i.Projects {
+			tags["+"+p] += task
+		}
+		for _, tag := range i.Tags {
+			tags["@"+tag] += task
+		}
+	}
+	t.walk(visit)
+	archive.walk(visit)
+
+	r.Tags = sortedLines(tags)
+	r.Days = sortedLines(days)
+
+	return r
+}
+
+func sortedLines(totals map[string]time.Duration) []ReportLine {
+	var lines []ReportLine
+	for name, total := range totals {
+		lines = append(lines, ReportLine{Name: name, Total: total})
+	}
+	sort.Slice(lines, func(a, b int) bool {
+		return lines[a].Name < lines[b].Name
+	})
+
+	return lines
+}
+
+func (r Report) Print() {
+
+	table := simpletable.New()
+
+	table.Header = &simpletable.Header{
+		Cells: []*simpletable.Cell{
+			{Align: simpletable.AlignCenter, Text: "Per"},
+			{Align: simpletable.AlignCenter, Text: "Name"},
+			{Align: simpletable.AlignRight, Text: "Time"},
+		},
+	}
+
+	var cells [][]*simpletable.Cell
+
+	for _, section := range []struct {
+		name  string
+		lines []ReportLine
+	}{
+		{"task", r.Tasks},
+		{"tag", r.Tags},
+		{"day", r.Days},
+	} {
+		for _, line := range section.lines {
+			cells = append(cells, []*simpletable.Cell{
+				{Text: gray(section.name)},
+				{Text: blue(line.Name)},
+				{Align: simpletable.AlignRight, Text: formatDuration(line.Total)},
+			})
+		}
+	}
+
+	table.Body = &simpletable.Body{Cells: cells}
+
+	footer := fmt.Sprintf("%s tracked in total", formatDuration(r.Total))
+	if !r.From.IsZero() {
+		footer += " from " + r.From.Format(time.RFC822)
+	}
+	if !r.To.IsZero() {
+		footer += " until " + r.To.Format(time.RFC822)
+	}
+
+	table.Footer = &simpletable.Footer{Cells: []*simpletable.Cell{
+		{Align: simpletable.AlignCenter, Span: 3, Text: green(footer)},
+	}}
+
+	table.SetStyle(simpletable.StyleUnicode)
+
+	table.Println()
+}
+
+// formatDuration writes d to the minute, e.g. "2h05m".
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
@@ -0,0 +1,158 @@
+package todo_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/example/todo"
+)
+
+func TestStartStop(t *testing.T) {
+	todos := todo.Todos{}
+	a := todos.Add("a")
+	b := todos.Add("b")
+	done := todos.Add("done")
+	todos.Complete(done)
+
+	if _, ok := todos.Running(); ok {
+		t.Error("a todo is running in a new list")
+	}
+	if _, err := todos.Stop(); err == nil {
+		t.Error("expected an error stopping with nothing running")
+	}
+
+	if err := todos.Start(a); err != nil {
+		t.Fatal(err)
+	}
+	if id, ok := todos.Running(); !ok || id != a {
+		t.Errorf("running %d, %v, want %d", id, ok, a)
+	}
+	if err := todos.Start(a); err == nil {
+		t.Error("expected an error starting the running todo")
+	}
+
+	// Only one todo runs at a time.
+	if err := todos.Start(b); err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := todos.Running(); id != b {
+		t.Errorf("running %d, want %d", id, b)
+	}
+	if todos[0].Running() || len(todos[0].Intervals) != 1 {
+		t.Errorf("a was not stopped: %+v", todos[0].Intervals)
+	}
+
+	if id, err := todos.Stop(); err != nil || id != b {
+		t.Errorf("Stop = %d, %v, want %d", id, err, b)
+	}
+	if _, ok := todos.Running(); ok {
+		t.Error("still running after Stop")
+	}
+
+	if err := todos.Start(done); err == nil {
+		t.Error("expected an error starting a completed todo")
+	}
+	if err := todos.Start(99); err == nil {
+		t.Error("expected an error starting an unknown todo")
+	}
+
+	// Completing the running todo stops it.
+	todos.Start(a)
+	todos.Complete(a)
+	if _, ok := todos.Running(); ok {
+		t.Error("completed todo still running")
+	}
+}
+
+func lines(ls []todo.ReportLine) []string {
+	var got []string
+	for _, l := range ls {
+		got = append(got, fmt.Sprintf("%s %s", l.Name, l.Total))
+	}
+
+	return got
+}
+
+func TestReport(t *testing.T) {
+	todos := decode(t, `[
+		{"ID":1,"Task":"client work","Projects":["acme"],"Tags":["billable"],"Intervals":[
+			{"Start":"2026-10-05T22:00:00Z","Stop":"2026-10-06T02:00:00Z"},
+			{"Start":"2026-10-07T09:00:00Z","Stop":"2026-10-07T10:00:00Z"}]},
+		{"ID":2,"Task":"admin","Tags":["billable"],"Intervals":[
+			{"Start":"2026-10-05T08:00:00Z","Stop":"2026-10-05T09:30:00Z"}]},
+		{"ID":4,"Task":"untracked"}]`)
+	// Todo 2 is in the archive as well, as archiving it was undone.
+	archive := decode(t, `[
+		{"ID":2,"Task":"admin","Tags":["billable"],"Intervals":[
+			{"Start":"2026-10-05T08:00:00Z","Stop":"2026-10-05T09:30:00Z"}]},
+		{"ID":3,"Task":"old","Projects":["acme"],"Done":true,"Intervals":[
+			{"Start":"2026-10-04T10:00:00Z","Stop":"2026-10-04T11:00:00Z"}]}]`)
+
+	at := func(day, hour int) time.Time { return time.Date(2026, 10, day, hour, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		total    time.Duration
+		tasks    []string
+		tags     []string
+		days     []string
+	}{
+		{
+			name:  "everything",
+			total: 7*time.Hour + 30*time.Minute,
+			tasks: []string{"1 client work 5h0m0s", "2 admin 1h30m0s", "3 old 1h0m0s"},
+			tags:  []string{"+acme 6h0m0s", "@billable 6h30m0s"},
+			days:  []string{"2026-10-04 1h0m0s", "2026-10-05 3h30m0s", "2026-10-06 2h0m0s", "2026-10-07 1h0m0s"},
+		},
+		{
+			name:  "clipped at the start",
+			from:  at(6, 0),
+			total: 3 * time.Hour,
+			tasks: []string{"1 client work 3h0m0s"},
+			tags:  []string{"+acme 3h0m0s", "@billable 3h0m0s"},
+			days:  []string{"2026-10-06 2h0m0s", "2026-10-07 1h0m0s"},
+		},
+		{
+			name:  "clipped at the end",
+			to:    at(5, 23),
+			total: 3*time.Hour + 30*time.Minute,
+			tasks: []string{"1 client work 1h0m0s", "2 admin 1h30m0s", "3 old 1h0m0s"},
+			tags:  []string{"+acme 2h0m0s", "@billable 2h30m0s"},
+			days:  []string{"2026-10-04 1h0m0s", "2026-10-05 2h30m0s"},
+		},
+		{
+			name:  "clipped at both ends",
+			from:  at(5, 9),
+			to:    at(6, 1),
+			total: 3*time.Hour + 30*time.Minute,
+			tasks: []string{"1 client work 3h0m0s", "2 admin 30m0s"},
+			tags:  []string{"+acme 3h0m0s", "@billable 3h30m0s"},
+			days:  []string{"2026-10-05 2h30m0s", "2026-10-06 1h0m0s"},
+		},
+		{
+			name: "nothing tracked",
+			from: at(8, 0),
+			to:   at(9, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := todos.Report(archive, tt.from, tt.to)
+			if r.Total != tt.total {
+				t.Errorf("got total %s, want %s", r.Total, tt.total)
+			}
+			if got := lines(r.Tasks); !equal(got, tt.tasks) {
+				t.Errorf("got tasks %q, want %q", got, tt.tasks)
+			}
+			if got := lines(r.Tags); !equal(got, tt.tags) {
+				t.Errorf("got tags %q, want %q", got, tt.tags)
+			}
+			if got := lines(r.Days); !equal(got, tt.days) {
+				t.Errorf("got days %q, want %q", got, tt.days)
+			}
+		})
+	}
+}
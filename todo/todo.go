@@ -1,141 +1,422 @@
 package todo
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/alexeyco/simpletable"
 )
 
 type item struct {
+	ID          int
 	Task        string
 	Done        bool
 	CreatedAt   time.Time
 	CompletedAt time.Time
+	Priority    Priority `json:",omitempty"`
+	DueAt       time.Time
+	Projects    []string    `json:",omitempty"`
+	Tags        []string    `json:",omitempty"`
+	Children    Todos       `json:",omitempty"`
+	Recur       *Recurrence `json:",omitempty"`
+	Intervals   []Interval  `json:",omitempty"`
+}
+
+func (i item) Overdue(now time.Time) bool {
+	return !i.Done && !i.DueAt.IsZero() && i.DueAt.Before(now)
 }
 
 type Todos []item
 
-func (t *Todos) Add(task string) {
+var ErrNotFound = errors.New("invalid id")
+
+// ErrEmpty is returned for a todo without text.
+var ErrEmpty = errors.New("empty todo is not allowed")
+
+// Add appends a todo and returns its ID, one past the highest ID in t. The
+// ID of a deleted todo may be handed out again; List keeps track of it.
+func (t *Todos) Add(task string) int {
+	return add(t, task, t.nextID)
+}
+
+// AddSub adds a subtask under the todo with the given parent ID.
+func (t *Todos) AddSub(parent int, task string) (int, error) {
+	return t.addSub(parent, task, t.nextID)
+}
+
+func (t *Todos) addSub(parent int, task string, next func() int) (int, error) {
+	ls, idx, err := t.find(parent)
+	if err != nil {
+		return 0, err
+	}
+
+	return add(&(*ls)[idx].Children, task, next), nil
+}
 
+// add appends a new todo to ls under the ID next hands out.
+func add(ls *Todos, task string, next func() int) int {
+
+	projects, tags := parseTags(task)
 	todo := item{
+		ID:          next(),
 		Task:        task,
 		Done:        false,
 		CreatedAt:   time.Now(),
 		CompletedAt: time.Time{},
+		Projects:    projects,
+		Tags:        tags,
 	}
 
-	*t = append(*t, todo)
+	*ls = append(*ls, todo)
+
+	return todo.ID
+}
+
+// Complete marks a todo as done. It is refused while the todo has open
+// subtasks; use ForceComplete to complete it and its subtasks together.
+func (t *Todos) Complete(id int) error {
+	return t.completeID(id, false, t.nextID)
 }
 
-func (t *Todos) Complete(index int) error {
-	ls := *t
-	if index <= 0 || index > len(ls) {
-		return errors.New("invalid index")
+func (t *Todos) ForceComplete(id int) error {
+	return t.completeID(id, true, t.nextID)
+}
+
+func (t *Todos) completeID(id int, force bool, next func() int) error {
+	ls, idx, err := t.find(id)
+	if err != nil {
+		return err
 	}
 
-	ls[index-1].CompletedAt = time.Now()
-	ls[index-1].Done = true
+	if n := (*ls)[idx].Children.CountPending(); n > 0 && !force {
+		return fmt.Errorf("todo %d has %d open subtasks", id, n)
+	}
+
+	completeAt(ls, idx, time.Now(), next)
 
 	return nil
 }
 
-func (t *Todos) Delete(index int) error {
-	ls := *t
-	if index <= 0 || index > len(ls) {
-		return errors.New("invalid index")
+// completeAt completes the todo at ls[idx]. A recurring todo hands its rule
+// on to a newly spawned next occurrence.
+func completeAt(ls *Todos, idx int, now time.Time, next func() int) {
+	(*ls)[idx].complete(now)
+
+	if (*ls)[idx].Recur != nil {
+		done := (*ls)[idx]
+		(*ls)[idx].Recur = nil
+		spawnNext(ls, done, now, next)
 	}
+}
 
-	*t = append(ls[:index-1], ls[index:]...)
+func (i *item) complete(now time.Time) {
+	for idx := range i.Children {
+		if !i.Children[idx].Done {
+			i.Children[idx].complete(now)
+		}
+	}
+
+	i.stop(now)
+	i.CompletedAt = now
+	i.Done = true
+}
+
+// Delete removes a todo together with all of its subtasks.
+func (t *Todos) Delete(id int) error {
+	ls, idx, err := t.find(id)
+	if err != nil {
+		return err
+	}
+
+	*ls = append((*ls)[:idx], (*ls)[idx+1:]...)
 
 	return nil
 }
 
-func (t *Todos) Load(filename string) error {
-	file, err := ioutil.ReadFile(filename)
+// Edit replaces the text of a todo. Tags written in the old text are
+// replaced by those in the new one; tags added by other means are kept.
+func (t *Todos) Edit(id int, task string) error {
+	ls, idx, err := t.find(id)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(task) == "" {
+		return ErrEmpty
+	}
+
+	i := &(*ls)[idx]
+	oldProjects, oldTags := parseTags(i.Task)
+	newProjects, newTags := parseTags(task)
+
+	i.Task = task
+	i.Projects = replaceTags(i.Projects, oldProjects, newProjects)
+	i.Tags = replaceTags(i.Tags, oldTags, newTags)
+
+	return nil
+}
+
+func (t *Todos) Reopen(id int) error {
+	ls, idx, err := t.find(id)
+	if err != nil {
+		return err
+	}
+
+	(*ls)[idx].Done = false
+	(*ls)[idx].CompletedAt = time.Time{}
+
+	return nil
+}
+
+// Move puts a todo at the given 1-based position among its siblings.
+func (t *Todos) Move(id int, position int) error {
+	ls, idx, err := t.find(id)
 	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return nil
-		}
 		return err
 	}
+	if position <= 0 || position > len(*ls) {
+		return errors.New("invalid position")
+	}
 
-	if len(file) == 0 {
+	moved := (*ls)[idx]
+	*ls = append((*ls)[:idx], (*ls)[idx+1:]...)
+	*ls = append((*ls)[:position-1], append(Todos{moved}, (*ls)[position-1:]...)...)
+
+	return nil
+}
+
+func (t *Todos) SetPriority(id int, priority Priority) error {
+	ls, idx, err := t.find(id)
+	if err != nil {
 		return err
 	}
-	err = json.Unmarshal(file, t)
+
+	(*ls)[idx].Priority = priority
+
+	return nil
+}
+
+func (t *Todos) SetDue(id int, due time.Time) error {
+	ls, idx, err := t.find(id)
 	if err != nil {
 		return err
 	}
 
+	(*ls)[idx].DueAt = due
+
 	return nil
 }
 
+// find returns the list holding the todo with the given ID, searching
+// subtasks too, and the todo's position in that list.
+func (t *Todos) find(id int) (*Todos, int, error) {
+	for idx := range *t {
+		if (*t)[idx].ID == id {
+			return t, idx, nil
+		}
+		if ls, cidx, err := (*t)[idx].Children.find(id); err == nil {
+			return ls, cidx, nil
+		}
+	}
+
+	return nil, -1, fmt.Errorf("%w %d", ErrNotFound, id)
+}
+
+// walk calls fn for every todo in tree order, with depth 0 for top-level todos.
+func (t *Todos) walk(fn func(depth int, i *item)) {
+	t.walkDepth(0, fn)
+}
+
+func (t *Todos) walkDepth(depth int, fn func(depth int, i *item)) {
+	for idx := range *t {
+		fn(depth, &(*t)[idx])
+		(*t)[idx].Children.walkDepth(depth+1, fn)
+	}
+}
+
+func (t *Todos) nextID() int {
+	max := 0
+	t.walk(func(_ int, i *item) {
+		if i.ID > max {
+			max = i.ID
+		}
+	})
+
+	return max + 1
+}
+
+// assignIDs gives an ID to every todo loaded from a file written before IDs existed.
+func (t *Todos) assignIDs() {
+	next := t.nextID()
+	t.walk(func(_ int, i *item) {
+		if i.ID == 0 {
+			i.ID = next
+			next++
+		}
+	})
+}
+
+func (t *Todos) Load(filename string) error {
+	l := List{Todos: *t}
+	err := l.Load(filename)
+	*t = l.Todos
+
+	return err
+}
+
+// PrettyJSON makes Store indent the JSON it writes, one field per line, so
+// that diffs of a task file kept in version control stay readable.
+var PrettyJSON = false
+
+// Store writes the todos to filename. A Todos carries no ID high-water mark,
+// so the one of the file being replaced is kept rather than lowered to the
+// highest ID left.
 func (t *Todos) Store(filename string) error {
+	l := List{Todos: *t}
+	if data, err := os.ReadFile(filename); err == nil {
+		if old, err := DecodeList(data); err == nil {
+			l.NextID = old.NextID
+		}
+	}
+
+	return l.Store(filename)
+}
 
-	data, err := json.Marshal(t)
+// writeFileAtomic writes data to a temporary file next to filename and renames
+// it into place, so a crash never leaves a half-written file behind.
+func writeFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".tmp*")
 	if err != nil {
 		return err
 	}
+	defer os.Remove(tmp.Name())
 
-	return ioutil.WriteFile(filename, data, 0644)
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filename)
 }
 
 func (t *Todos) Print() {
+	t.PrintFiltered(Filter{})
+}
+
+func (t *Todos) PrintFiltered(f Filter) {
+	t.table(f, DefaultColumns, nil).Println()
+}
+
+// table lays out the named columns of the todos matching f. If row is not nil
+// it is called with each todo's cells and may change them; the row is left
+// out if it returns false.
+func (t *Todos) table(f Filter, names []string, row func(id int, cells []*simpletable.Cell) bool) *simpletable.Table {
+	selected := t.Select(f)
 
 	table := simpletable.New()
 
-	table.Header = &simpletable.Header{
-		Cells: []*simpletable.Cell{
-			{Align: simpletable.AlignCenter, Text: "#"},
-			{Align: simpletable.AlignCenter, Text: "Task"},
-			{Align: simpletable.AlignCenter, Text: "Done?"},
-			{Align: simpletable.AlignRight, Text: "CreatedAt"},
-			{Align: simpletable.AlignRight, Text: "CompletedAt"},
-		},
+	table.Header = &simpletable.Header{}
+	for _, name := range names {
+		table.Header.Cells = append(table.Header.Cells, &simpletable.Cell{Align: columns[name].align, Text: columns[name].header})
 	}
 
 	var cells [][]*simpletable.Cell
 
-	for idx, item := range *t {
-		idx++
-		task := blue(item.Task)
-		done := blue("no")
-		if item.Done {
-			task = green(fmt.Sprintf("\u2705 %s", item.Task))
-			done = green("yes")
+	now := time.Now()
+	t.walk(func(depth int, i *item) {
+		if !f.Match(*i) {
+			return
 		}
-		cells = append(cells, *&[]*simpletable.Cell{
-			{Text: fmt.Sprintf("%d", idx)},
-			{Text: task},
-			{Text: done},
-			{Text: item.CreatedAt.Format(time.RFC822)},
-			{Text: item.CompletedAt.Format(time.RFC822)},
-		})
-	}
+
+		var rowCells []*simpletable.Cell
+		for _, name := range names {
+			rowCells = append(rowCells, &simpletable.Cell{Text: tableCell(name, *i, depth, now)})
+		}
+		if row != nil && !row(i.ID, rowCells) {
+			return
+		}
+		cells = append(cells, rowCells)
+	})
 
 	table.Body = &simpletable.Body{Cells: cells}
 
+	footer := fmt.Sprintf("You have %d pending todos", selected.CountPending())
+	if filter := f.String(); filter != "" {
+		footer = fmt.Sprintf("You have %d pending todos in %s", selected.CountPending(), filter)
+	}
+
 	table.Footer = &simpletable.Footer{Cells: []*simpletable.Cell{
-		{Align: simpletable.AlignCenter, Span: 5, Text: red(fmt.Sprintf("You have %d pending todos", t.CountPending()))},
+		{Align: simpletable.AlignCenter, Span: len(names), Text: red(footer)},
 	}}
 
 	table.SetStyle(simpletable.StyleUnicode)
 
-	table.Println()
+	return table
 }
 
+// tableCell formats a column of the table, coloring it by the todo's state.
+func tableCell(name string, item item, depth int, now time.Time) string {
+	text := formatValue(columns[name].value(item, now))
+
+	switch name {
+	case "task":
+		indent := ""
+		if depth > 0 {
+			// The zero-width space stops simpletable from trimming the indentation.
+			indent = "\u200b" + strings.Repeat("  ", depth-1) + "\u2514 "
+		}
+		switch {
+		case item.Done:
+			text = green(fmt.Sprintf("\u2705 %s", text))
+		case item.Overdue(now):
+			text = red(text)
+		default:
+			text = blue(text)
+		}
+		return indent + text
+	case "done":
+		switch {
+		case item.Done:
+			return green(text)
+		case item.Overdue(now):
+			return red(text)
+		default:
+			return blue(text)
+		}
+	case "due":
+		if item.Overdue(now) {
+			return red(text)
+		}
+	case "elapsed":
+		if item.Running() {
+			return green("\u25b6 " + text)
+		}
+	}
+
+	return text
+}
+
+// CountPending counts the todos that are not done, subtasks included.
 func (t *Todos) CountPending() int {
 	total := 0
-	for _, item := range *t {
-		if !item.Done {
+	t.walk(func(_ int, i *item) {
+		if !i.Done {
 			total++
 		}
-	}
+	})
 
 	return total
 }
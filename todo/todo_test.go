@@ -0,0 +1,209 @@
+package todo_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/example/todo"
+)
+
+func tasks(todos todo.Todos) []string {
+	var got []string
+	for _, item := range todos {
+		got = append(got, item.Task)
+	}
+
+	return got
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestEdit(t *testing.T) {
+	todos := todo.Todos{}
+	id := todos.Add("ship it +release @work")
+	todos.AddTags(id, nil, []string{"urgent"})
+
+	if err := todos.Edit(id, "ship it +hotfix"); err != nil {
+		t.Fatal(err)
+	}
+
+	item := todos[0]
+	if item.Task != "ship it +hotfix" {
+		t.Errorf("got task %q", item.Task)
+	}
+	if !equal(item.Projects, []string{"hotfix"}) {
+		t.Errorf("got projects %v, want [hotfix]", item.Projects)
+	}
+	if !equal(item.Tags, []string{"urgent"}) {
+		t.Errorf("got tags %v, want [urgent]", item.Tags)
+	}
+
+	if err := todos.Edit(id, "  "); err == nil {
+		t.Error("expected an error for empty text")
+	}
+	if err := todos.Edit(id+1, "nope"); err == nil {
+		t.Error("expected an error for an unknown id")
+	}
+}
+
+func TestReopen(t *testing.T) {
+	todos := todo.Todos{}
+	id := todos.Add("study")
+
+	if err := todos.Complete(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := todos.Reopen(id); err != nil {
+		t.Fatal(err)
+	}
+
+	if todos[0].Done || !todos[0].CompletedAt.IsZero() {
+		t.Errorf("todo still completed: %+v", todos[0])
+	}
+	if err := todos.Reopen(id + 1); err == nil {
+		t.Error("expected an error for an unknown id")
+	}
+}
+
+func TestMove(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       int
+		position int
+		want     []string
+		wantErr  bool
+	}{
+		{name: "to front", id: 3, position: 1, want: []string{"c", "a", "b"}},
+		{name: "to back", id: 1, position: 3, want: []string{"b", "c", "a"}},
+		{name: "same place", id: 2, position: 2, want: []string{"a", "b", "c"}},
+		{name: "position zero", id: 1, position: 0, want: []string{"a", "b", "c"}, wantErr: true},
+		{name: "past the end", id: 1, position: 4, want: []string{"a", "b", "c"}, wantErr: true},
+		{name: "unknown id", id: 9, position: 1, want: []string{"a", "b", "c"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			todos := todo.Todos{}
+			todos.Add("a")
+			todos.Add("b")
+			todos.Add("c")
+
+			err := todos.Move(tt.id, tt.position)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if got := tasks(todos); !equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoveSubtask(t *testing.T) {
+	todos := todo.Todos{}
+	parent := todos.Add("release")
+	todos.AddSub(parent, "a")
+	b, _ := todos.AddSub(parent, "b")
+
+	if err := todos.Move(b, 1); err != nil {
+		t.Fatal(err)
+	}
+	if got := tasks(todos[0].Children); !equal(got, []string{"b", "a"}) {
+		t.Errorf("got %v, want [b a]", got)
+	}
+	if err := todos.Move(b, 3); err == nil {
+		t.Error("expected an error for a position past the siblings")
+	}
+}
+
+func TestSelectIDs(t *testing.T) {
+	todos := todo.Todos{}
+	for _, task := range []string{"a", "b", "c", "d", "e"} {
+		todos.Add(task)
+	}
+	todos.Delete(4)
+	todos.Complete(2)
+
+	tests := []struct {
+		sel  string
+		want []int
+	}{
+		{"3", []int{3}},
+		{"1,3", []int{1, 3}},
+		{"3, 1", []int{1, 3}},
+		{"2-5", []int{2, 3, 5}},
+		{"done:true", []int{2}},
+		{"done:false text~e", []int{5}},
+	}
+	for _, tt := range tests {
+		got, err := todos.SelectIDs(tt.sel)
+		if err != nil {
+			t.Errorf("%s: %v", tt.sel, err)
+			continue
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.sel, got, tt.want)
+		}
+	}
+
+	for _, sel := range []string{"", "4", "1,4", "3-1", "0", "6-9", "text~zzz"} {
+		if got, err := todos.SelectIDs(sel); err == nil {
+			t.Errorf("%q: got %v, want an error", sel, got)
+		}
+	}
+}
+
+func TestCompleteAll(t *testing.T) {
+	todos := todo.Todos{}
+	parent := todos.Add("parent")
+	child, _ := todos.AddSub(parent, "child")
+	other := todos.Add("other")
+
+	if err := todos.CompleteAll([]int{parent, other}, false); err == nil {
+		t.Fatal("expected an error for a parent with an open subtask")
+	}
+	if todos[1].Done {
+		t.Error("a failed bulk completion changed the list")
+	}
+
+	if err := todos.CompleteAll([]int{parent, child, other}, false); err != nil {
+		t.Fatal(err)
+	}
+	if !todos[0].Done || !todos[0].Children[0].Done || !todos[1].Done {
+		t.Errorf("not all todos were completed: %+v", todos)
+	}
+}
+
+func TestDeleteAll(t *testing.T) {
+	todos := todo.Todos{}
+	for _, task := range []string{"a", "b", "c", "d"} {
+		todos.Add(task)
+	}
+	todos.AddSub(3, "c1")
+
+	if err := todos.DeleteAll([]int{1, 9}); err == nil {
+		t.Fatal("expected an error for an unknown id")
+	}
+	if len(todos) != 4 {
+		t.Fatalf("a failed bulk delete changed the list: %v", tasks(todos))
+	}
+
+	// Removing the first todo must not make 3 name what was d.
+	if err := todos.DeleteAll([]int{1, 3}); err != nil {
+		t.Fatal(err)
+	}
+	if !equal(tasks(todos), []string{"b", "d"}) {
+		t.Errorf("got %v, want [b d]", tasks(todos))
+	}
+}
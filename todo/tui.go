@@ -0,0 +1,428 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/alexeyco/simpletable"
+	"golang.org/x/term"
+)
+
+const (
+	modeNormal = iota
+	modeAdd
+	modeAddSub
+	modeEdit
+	modeFilter
+	modeConfirmDelete
+)
+
+const tuiHelp = "j/k move  space toggle  a add  s subtask  e edit  d delete  / filter  q quit"
+
+// tui is the state of the interactive, full screen list. Every change is
+// made through a SharedList, so it is stored and journaled straight away
+// and other processes may use the list while the screen is open.
+type tui struct {
+	list *SharedList
+	in   *os.File
+	out  *os.File
+
+	todos  Todos
+	ids    []int
+	cursor int
+	top    int
+
+	mode   int
+	input  []rune
+	filter string
+	status string
+}
+
+// Interactive runs the full screen list on the terminal until the user quits.
+func Interactive(list *SharedList, in, out *os.File) error {
+	if !term.IsTerminal(int(in.Fd())) || !term.IsTerminal(int(out.Fd())) {
+		return errors.New("interactive mode needs a terminal")
+	}
+
+	state, err := term.MakeRaw(int(in.Fd()))
+	if err != nil {
+		return err
+	}
+	defer term.Restore(int(in.Fd()), state)
+
+	// Switch to the alternate screen and hide the cursor while we run.
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(out, "\x1b[?25h\x1b[?1049l")
+
+	ui := &tui{list: list, in: in, out: out}
+	if err := ui.reload(); err != nil {
+		return err
+	}
+
+	for {
+		ui.render()
+
+		keys, err := ui.readKeys()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		for _, key := range keys {
+			if quit := ui.handle(key); quit {
+				return nil
+			}
+		}
+	}
+}
+
+// reload loads the list afresh and works out which todos are visible.
+func (ui *tui) reload() error {
+	selected := ui.selectedID()
+
+	err := ui.list.View(func(t *Todos) error {
+		ui.todos = *t
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	f := Filter{Text: ui.filter}
+	ui.ids = ui.ids[:0]
+	ui.todos.walk(func(_ int, i *item) {
+		if f.Match(*i) {
+			ui.ids = append(ui.ids, i.ID)
+		}
+	})
+
+	ui.selectID(selected)
+
+	return nil
+}
+
+func (ui *tui) selectedID() int {
+	if ui.cursor < 0 || ui.cursor >= len(ui.ids) {
+		return 0
+	}
+
+	return ui.ids[ui.cursor]
+}
+
+// selectID moves the cursor to the todo with the given ID, or keeps it
+// within the list if that todo is no longer shown.
+func (ui *tui) selectID(id int) {
+	for idx, visible := range ui.ids {
+		if visible == id {
+			ui.cursor = idx
+			return
+		}
+	}
+
+	if ui.cursor >= len(ui.ids) {
+		ui.cursor = len(ui.ids) - 1
+	}
+	if ui.cursor < 0 {
+		ui.cursor = 0
+	}
+}
+
+// update applies a change through the shared list and shows the outcome.
+func (ui *tui) update(fn func(t *List) (string, error)) {
+	var description string
+	err := ui.list.Update(func(t *List) (string, error) {
+		var err error
+		description, err = fn(t)
+		return description, err
+	})
+	if err != nil {
+		ui.status = red(err.Error())
+	} else {
+		ui.status = green(description)
+	}
+
+	if err := ui.reload(); err != nil {
+		ui.status = red(err.Error())
+	}
+}
+
+func (ui *tui) render() {
+	height := 24
+	if _, h, err := term.GetSize(int(ui.out.Fd())); err == nil {
+		height = h
+	}
+
+	// The table borders, header and footer take six lines, and the status
+	// and help lines two more.
+	fit := height - 8
+	if fit < 1 {
+		fit = 1
+	}
+	if ui.cursor < ui.top {
+		ui.top = ui.cursor
+	}
+	if ui.cursor >= ui.top+fit {
+		ui.top = ui.cursor - fit + 1
+	}
+
+	shown := map[int]bool{}
+	for idx := ui.top; idx < len(ui.ids) && idx < ui.top+fit; idx++ {
+		shown[ui.ids[idx]] = true
+	}
+	selected := ui.selectedID()
+
+	table := ui.todos.table(Filter{Text: ui.filter}, DefaultColumns, func(id int, cells []*simpletable.Cell) bool {
+		if id == selected {
+			cells[0].Text = reverse(fmt.Sprintf("▶ %d", id))
+		}
+		return shown[id]
+	})
+
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J")
+	b.WriteString(strings.ReplaceAll(table.String(), "\n", "\r\n"))
+	b.WriteString("\r\n")
+
+	switch ui.mode {
+	case modeAdd:
+		b.WriteString("New todo: " + string(ui.input) + reverse(" "))
+	case modeAddSub:
+		b.WriteString(fmt.Sprintf("New subtask of %d: %s%s", selected, string(ui.input), reverse(" ")))
+	case modeEdit:
+		b.WriteString(fmt.Sprintf("Edit %d: %s%s", selected, string(ui.input), reverse(" ")))
+	case modeFilter:
+		b.WriteString("Filter: " + string(ui.input) + reverse(" "))
+	case modeConfirmDelete:
+		b.WriteString(red(fmt.Sprintf("Delete todo %d and its subtasks? (y/n)", selected)))
+	default:
+		b.WriteString(ui.status)
+	}
+	b.WriteString("\r\n" + gray(tuiHelp))
+
+	io.WriteString(ui.out, b.String())
+}
+
+const (
+	keyUp        = "\x00up"
+	keyDown      = "\x00down"
+	keyEnter     = "\x00enter"
+	keyEscape    = "\x00esc"
+	keyBackspace = "\x00backspace"
+	keyInterrupt = "\x00interrupt"
+)
+
+// readKeys reads what is waiting on the terminal and splits it into key
+// presses. Runs of typed or pasted text are returned as one key.
+func (ui *tui) readKeys() ([]string, error) {
+	buf := make([]byte, 256)
+	n, err := ui.in.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	buf = buf[:n]
+
+	var keys []string
+	for len(buf) > 0 {
+		switch {
+		case len(buf) >= 3 && buf[0] == 0x1b && buf[1] == '[':
+			switch buf[2] {
+			case 'A':
+				keys = append(keys, keyUp)
+			case 'B':
+				keys = append(keys, keyDown)
+			}
+			buf = buf[3:]
+			continue
+		case buf[0] == 0x1b:
+			keys = append(keys, keyEscape)
+		case buf[0] == '\r' || buf[0] == '\n':
+			keys = append(keys, keyEnter)
+		case buf[0] == 0x7f || buf[0] == 0x08:
+			keys = append(keys, keyBackspace)
+		case buf[0] == 0x03 || buf[0] == 0x04:
+			keys = append(keys, keyInterrupt)
+		case buf[0] < 0x20:
+			// Other control keys are ignored.
+		default:
+			end := 1
+			for end < len(buf) && buf[end] >= 0x20 && buf[end] != 0x7f {
+				end++
+			}
+			keys = append(keys, string(buf[:end]))
+			buf = buf[end:]
+			continue
+		}
+		buf = buf[1:]
+	}
+
+	return keys, nil
+}
+
+// handle acts on a key and reports whether the user wants to quit.
+func (ui *tui) handle(key string) bool {
+	if key == keyInterrupt {
+		return true
+	}
+
+	// Outside of text input every typed character is a command of its own.
+	inputting := ui.mode != modeNormal && ui.mode != modeConfirmDelete
+	if !inputting && !strings.HasPrefix(key, "\x00") && utf8.RuneCountInString(key) > 1 {
+		for _, r := range key {
+			if ui.handle(string(r)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch ui.mode {
+	case modeNormal:
+		return ui.handleNormal(key)
+	case modeConfirmDelete:
+		ui.mode = modeNormal
+		if key == "y" || key == "Y" {
+			id := ui.selectedID()
+			ui.update(func(t *List) (string, error) {
+				return fmt.Sprintf("delete %d", id), t.Delete(id)
+			})
+		} else {
+			ui.status = ""
+		}
+	default:
+		ui.handleInput(key)
+	}
+
+	return false
+}
+
+func (ui *tui) handleNormal(key string) bool {
+	id := ui.selectedID()
+	ui.status = ""
+
+	switch key {
+	case "q":
+		return true
+	case "j", keyDown:
+		if ui.cursor < len(ui.ids)-1 {
+			ui.cursor++
+		}
+	case "k", keyUp:
+		if ui.cursor > 0 {
+			ui.cursor--
+		}
+	case " ", "x":
+		if id == 0 {
+			break
+		}
+		ui.update(func(t *List) (string, error) {
+			ls, idx, err := t.find(id)
+			if err != nil {
+				return "", err
+			}
+			if (*ls)[idx].Done {
+				return fmt.Sprintf("reopen %d", id), t.Reopen(id)
+			}
+			return fmt.Sprintf("complete %d", id), t.Complete(id)
+		})
+	case "a":
+		ui.startInput(modeAdd, "")
+	case "s":
+		if id != 0 {
+			ui.startInput(modeAddSub, "")
+		}
+	case "e":
+		if id != 0 {
+			ls, idx, err := ui.todos.find(id)
+			if err == nil {
+				ui.startInput(modeEdit, (*ls)[idx].Task)
+			}
+		}
+	case "d":
+		if id != 0 {
+			ui.mode = modeConfirmDelete
+		}
+	case "/":
+		ui.startInput(modeFilter, ui.filter)
+	}
+
+	return false
+}
+
+func (ui *tui) startInput(mode int, text string) {
+	ui.mode = mode
+	ui.input = []rune(text)
+}
+
+func (ui *tui) handleInput(key string) {
+	switch key {
+	case keyEscape:
+		if ui.mode == modeFilter {
+			ui.filter = ""
+			ui.reload()
+		}
+		ui.mode = modeNormal
+		return
+	case keyBackspace:
+		if len(ui.input) > 0 {
+			ui.input = ui.input[:len(ui.input)-1]
+		}
+	case keyEnter:
+		ui.submit()
+		return
+	case keyUp, keyDown:
+		return
+	default:
+		for len(key) > 0 {
+			r, size := utf8.DecodeRuneInString(key)
+			key = key[size:]
+			if unicode.IsPrint(r) {
+				ui.input = append(ui.input, r)
+			}
+		}
+	}
+
+	if ui.mode == modeFilter {
+		ui.filter = string(ui.input)
+		ui.reload()
+	}
+}
+
+func (ui *tui) submit() {
+	mode := ui.mode
+	text := strings.TrimSpace(string(ui.input))
+	id := ui.selectedID()
+	ui.mode = modeNormal
+
+	if mode == modeFilter {
+		return
+	}
+	if text == "" {
+		ui.status = red("empty todo is not allowed")
+		return
+	}
+
+	var added int
+	ui.update(func(t *List) (string, error) {
+		switch mode {
+		case modeAdd:
+			added = t.Add(text)
+			return fmt.Sprintf("add %d: %s", added, text), nil
+		case modeAddSub:
+			var err error
+			added, err = t.AddSub(id, text)
+			return fmt.Sprintf("add %d: %s", added, text), err
+		default:
+			return fmt.Sprintf("edit %d: %s", id, text), t.Edit(id, text)
+		}
+	})
+	if added != 0 {
+		ui.selectID(added)
+	}
+}
@@ -0,0 +1,212 @@
+package todo
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// testTUI is the interactive list on a file with the todos "one", "two" and
+// "three", read from a pipe instead of a terminal.
+type testTUI struct {
+	*tui
+	t    *testing.T
+	path string
+	keys *os.File
+}
+
+func newTestTUI(t *testing.T) *testTUI {
+	path := filepath.Join(t.TempDir(), "todos.json")
+	l := &List{}
+	for _, task := range []string{"one", "two", "three"} {
+		l.Add(task)
+	}
+	if err := l.Store(path); err != nil {
+		t.Fatal(err)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		r.Close()
+		w.Close()
+	})
+
+	ui := &tui{list: NewSharedList(&JSONFile{Path: path}, path), in: r}
+	if err := ui.reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	return &testTUI{tui: ui, t: t, path: path, keys: w}
+}
+
+// press types s on the terminal and handles the keys read, reporting
+// whether they quit.
+func (ui *testTUI) press(s string) bool {
+	ui.t.Helper()
+
+	if _, err := ui.keys.Write([]byte(s)); err != nil {
+		ui.t.Fatal(err)
+	}
+	keys, err := ui.readKeys()
+	if err != nil {
+		ui.t.Fatal(err)
+	}
+	for _, key := range keys {
+		if ui.handle(key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// stored loads the list from the file.
+func (ui *testTUI) stored() Todos {
+	ui.t.Helper()
+
+	l := &List{}
+	if err := l.Load(ui.path); err != nil {
+		ui.t.Fatal(err)
+	}
+
+	return l.Todos
+}
+
+func TestTUIReadKeys(t *testing.T) {
+	ui := newTestTUI(t)
+
+	if _, err := ui.keys.Write([]byte("\x1b[A\x1b[Bjk new\r\x7f\x01\x1b\x03")); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := ui.readKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{keyUp, keyDown, "jk new", keyEnter, keyBackspace, keyEscape, keyInterrupt}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("got %q, want %q", keys, want)
+	}
+}
+
+func TestTUIMove(t *testing.T) {
+	ui := newTestTUI(t)
+
+	tests := []struct {
+		keys string
+		want int
+	}{
+		{"j", 1},
+		{"\x1b[B", 2},
+		{"j", 2},
+		{"k", 1},
+		{"\x1b[A", 0},
+		{"k", 0},
+		{"jj", 2},
+	}
+
+	for _, tt := range tests {
+		if ui.press(tt.keys); ui.selectedID() != tt.want+1 {
+			t.Errorf("after %q selected %d, want %d", tt.keys, ui.selectedID(), tt.want+1)
+		}
+	}
+
+	if !ui.press("q") {
+		t.Error("q did not quit")
+	}
+}
+
+func TestTUIToggle(t *testing.T) {
+	ui := newTestTUI(t)
+
+	ui.press("j ")
+	if stored := ui.stored(); !stored[1].Done || stored[0].Done {
+		t.Errorf("space completed the wrong todo: %+v", stored)
+	}
+	if ui.selectedID() != 2 {
+		t.Errorf("selected %d after toggling, want 2", ui.selectedID())
+	}
+
+	ui.press("x")
+	if stored := ui.stored(); stored[1].Done {
+		t.Error("x did not reopen the todo")
+	}
+}
+
+func TestTUIDelete(t *testing.T) {
+	ui := newTestTUI(t)
+
+	ui.press("jd")
+	if ui.mode != modeConfirmDelete {
+		t.Fatalf("d did not ask for confirmation")
+	}
+	ui.press("n")
+	if got := len(ui.stored()); got != 3 {
+		t.Errorf("deleted without confirmation, %d todos left", got)
+	}
+
+	ui.press("dy")
+	if got := tasks(ui.stored()); !reflect.DeepEqual(got, []string{"one", "three"}) {
+		t.Errorf("got %v after deleting two", got)
+	}
+	if ui.selectedID() != 3 {
+		t.Errorf("selected %d after deleting, want 3", ui.selectedID())
+	}
+}
+
+func TestTUIFilter(t *testing.T) {
+	ui := newTestTUI(t)
+
+	ui.press("/")
+	ui.press("t")
+	if want := []int{2, 3}; !reflect.DeepEqual(ui.ids, want) {
+		t.Errorf("filter t shows %v, want %v", ui.ids, want)
+	}
+	ui.press("hx\x7f")
+	if want := []int{3}; ui.filter != "th" || !reflect.DeepEqual(ui.ids, want) {
+		t.Errorf("filter %q shows %v, want th and %v", ui.filter, ui.ids, want)
+	}
+
+	// Enter keeps the filter, so keys move within what it shows.
+	ui.press("\r")
+	if ui.mode != modeNormal || ui.filter != "th" {
+		t.Errorf("enter left mode %d, filter %q", ui.mode, ui.filter)
+	}
+	ui.press("k")
+	if ui.selectedID() != 3 {
+		t.Errorf("selected %d, want 3", ui.selectedID())
+	}
+
+	// Escape while typing one drops it.
+	ui.press("/")
+	ui.press("\x1b")
+	if ui.filter != "" || len(ui.ids) != 3 {
+		t.Errorf("escape left filter %q showing %v", ui.filter, ui.ids)
+	}
+}
+
+func TestTUIAdd(t *testing.T) {
+	ui := newTestTUI(t)
+
+	ui.press("a")
+	ui.press("four\r")
+	if got := tasks(ui.stored()); !reflect.DeepEqual(got, []string{"one", "two", "three", "four"}) {
+		t.Errorf("got %v", got)
+	}
+	if ui.selectedID() != 4 {
+		t.Errorf("selected %d after adding, want the new todo", ui.selectedID())
+	}
+}
+
+func tasks(todos Todos) []string {
+	var got []string
+	for _, i := range todos {
+		got = append(got, i.Task)
+	}
+
+	return got
+}